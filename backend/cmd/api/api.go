@@ -6,9 +6,17 @@ import (
 	"formaura/cmd/api/routes"
 	user_memory_cache "formaura/pkg/cache/user_memory"
 	"formaura/pkg/email"
+	"formaura/pkg/exportjob"
 	"formaura/pkg/middleware"
+	event_repo "formaura/pkg/repositories/event"
+	export_repo "formaura/pkg/repositories/export"
 	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	template_repo "formaura/pkg/repositories/template"
 	user_repo "formaura/pkg/repositories/user"
+	"formaura/pkg/storage"
+	"formaura/pkg/trash"
+	"formaura/pkg/views"
 	"log"
 	"net/http"
 	"time"
@@ -17,7 +25,26 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
-func NewAPI(ctx context.Context, pool *pgxpool.Pool, client *http.Client) (*http.Server, error) {
+// API is the http server plus the background workers that live alongside it
+type API struct {
+	*http.Server
+	trashSweeper *trash.Sweeper
+	viewCounter  *views.Counter
+	exportWorker *exportjob.Worker
+}
+
+// Shutdown stops accepting requests first, then the background workers
+func (a *API) Shutdown(ctx context.Context) error {
+	err := a.Server.Shutdown(ctx)
+	a.trashSweeper.Stop()
+	// a running export is requeued for the next instance to pick up
+	a.exportWorker.Stop()
+	// flush buffered views last so none recorded during shutdown are lost
+	a.viewCounter.Stop()
+	return err
+}
+
+func NewAPI(ctx context.Context, pool *pgxpool.Pool, client *http.Client) (*API, error) {
 
 	TWO_HOURS := 2 * time.Hour
 
@@ -33,14 +60,35 @@ func NewAPI(ctx context.Context, pool *pgxpool.Pool, client *http.Client) (*http
 	//repositories
 	userRepo := user_repo.NewUserRepo(pool)
 	formRepo := form_repo.NewFormRepo(pool)
+	submissionRepo := submission_repo.NewSubmissionRepo(pool)
+	templateRepo := template_repo.NewTemplateRepo(pool)
+	eventRepo := event_repo.NewEventRepo(pool)
+	exportRepo := export_repo.NewExportRepo(pool)
+
+	//storage
+	exportStorage, err := storage.NewDisk(storage.Dir())
+
+	if err != nil {
+		log.Fatalf("Export storage failed to init: %v", err)
+	}
+
+	downloadSigner := storage.NewSigner(storage.Secret(), storage.BaseURL())
+
+	//background workers
+	trashRetention := trash.Retention()
+	trashSweeper := trash.NewSweeper(formRepo, trashRetention)
+	viewCounter := views.NewCounter(formRepo)
+	exportWorker := exportjob.NewWorker(exportRepo, formRepo, submissionRepo, userRepo, exportStorage, downloadSigner, emailClient, exportjob.Retention())
 
 	//handlers
 	authHandlers := handlers.NewAuthHandler(userRepo, userCache, emailClient)
-	formHandlers := handlers.NewFormHandler(formRepo, userCache, emailClient)
-	submissionHandlers := handlers.NewSubmissionHandler(formRepo, emailClient)
+	formHandlers := handlers.NewFormHandler(formRepo, templateRepo, eventRepo, submissionRepo, exportRepo, userCache, emailClient, trashRetention)
+	submissionHandlers := handlers.NewSubmissionHandler(formRepo, submissionRepo, eventRepo, viewCounter, emailClient)
+	exportHandlers := handlers.NewExportHandler(exportRepo, formRepo, exportStorage, downloadSigner)
 
 	authFresh := middleware.AuthAlwaysFreshMiddleware(userRepo, userCache)
 	authCached := middleware.AuthCachedMiddleware(userRepo, userCache)
+	authOptional := middleware.AuthOptionalMiddleware(userRepo, userCache)
 
 	//router
 	r := mux.NewRouter()
@@ -55,13 +103,24 @@ func NewAPI(ctx context.Context, pool *pgxpool.Pool, client *http.Client) (*http
 		authHandlers,
 		formHandlers,
 		submissionHandlers,
+		exportHandlers,
 		//middleware
 		authFresh,
 		authCached,
+		authOptional,
 	)
 
-	return &http.Server{
-		Addr:    PORT,
-		Handler: r,
+	trashSweeper.Start()
+	viewCounter.Start()
+	exportWorker.Start()
+
+	return &API{
+		Server: &http.Server{
+			Addr:    PORT,
+			Handler: r,
+		},
+		trashSweeper: trashSweeper,
+		viewCounter:  viewCounter,
+		exportWorker: exportWorker,
 	}, nil
 }
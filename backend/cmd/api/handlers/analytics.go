@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"formaura/pkg/analytics"
+	"formaura/pkg/output"
+	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	"formaura/pkg/validate"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 3 * 366
+)
+
+type GetAnalyticsResponse struct {
+	Analytics *analytics.Report `json:"analytics"`
+}
+
+type GetFunnelResponse struct {
+	Funnel *analytics.Funnel `json:"funnel"`
+}
+
+type GetFieldsReportResponse struct {
+	Report *analytics.FieldsReport `json:"report"`
+}
+
+// getAnalyticsRange reads ?from=&to= as inclusive days, defaulting to the last
+// 30 days
+func getAnalyticsRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	to := analytics.PeriodStart(time.Now().UTC(), analytics.GranularityDay)
+	if s := query.Get("to"); s != "" {
+		t, ok := validate.ParseDate(s)
+		if !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("to is invalid")
+		}
+		to = analytics.PeriodStart(t, analytics.GranularityDay)
+	}
+
+	from := to.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if s := query.Get("from"); s != "" {
+		t, ok := validate.ParseDate(s)
+		if !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("from is invalid")
+		}
+		from = analytics.PeriodStart(t, analytics.GranularityDay)
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+
+	if to.Sub(from) > maxAnalyticsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("Date range is too large")
+	}
+
+	return from, to, nil
+}
+
+func (h *FormHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	from, to, err := getAnalyticsRange(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	granularity := r.URL.Query().Get("granularity")
+
+	if granularity == "" {
+		granularity = analytics.GranularityDay
+	}
+
+	if !slices.Contains(analytics.ValidGranularities, granularity) {
+		return http.StatusBadRequest, fmt.Errorf("Invalid granularity value")
+	}
+
+	stats, err := h.FormRepo.GetDailyStats(r.Context(), form.ID, from, to.AddDate(0, 0, 1))
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	report, err := analytics.Rollup(stats, from, to, granularity)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &GetAnalyticsResponse{
+		Analytics: report,
+	})
+}
+
+// getReportFormData returns the published form data reports are laid out by,
+// or the draft's for a form that has never been published
+func (h *FormHandler) getReportFormData(r *http.Request, form *form_repo.FormModel) (*form_repo.FormData, error) {
+	if form.PublishedVersionID != nil {
+		version, err := h.FormRepo.GetVersionByID(r.Context(), *form.PublishedVersionID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		form.ApplyVersion(version)
+	}
+
+	var formData form_repo.FormData
+
+	if err := form.UnmarshalFormData(&formData); err != nil {
+		return nil, err
+	}
+
+	return &formData, nil
+}
+
+// GetFunnel reports how far sessions get through the form's steps
+func (h *FormHandler) GetFunnel(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	from, to, err := getAnalyticsRange(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	formData, err := h.getReportFormData(r, form)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	before := to.AddDate(0, 0, 1)
+
+	started, err := h.EventRepo.CountStarts(r.Context(), form.ID, from, before)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	stats, err := h.EventRepo.GetStepStats(r.Context(), form.ID, from, before)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &GetFunnelResponse{
+		Funnel: analytics.BuildFunnel(formData, started, stats),
+	})
+}
+
+// GetFieldsReport breaks down the answers to the form's choice, number and date
+// fields, optionally for one affiliate and a range of submission dates
+func (h *FormHandler) GetFieldsReport(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	filter := submission_repo.Filter{}
+
+	filter.From, filter.Before, err = GetDateRangeFromQuery(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if affiliate := r.URL.Query().Get("affiliate"); affiliate != "" {
+		if !validate.ValidateUUID(affiliate) {
+			return http.StatusBadRequest, fmt.Errorf("Incorrect affiliate uuid format")
+		}
+		filter.AffiliateUUID = &affiliate
+	}
+
+	granularity := r.URL.Query().Get("granularity")
+
+	if granularity == "" {
+		granularity = analytics.GranularityMonth
+	}
+
+	if !slices.Contains(analytics.ValidGranularities, granularity) {
+		return http.StatusBadRequest, fmt.Errorf("Invalid granularity value")
+	}
+
+	formData, err := h.getReportFormData(r, form)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	submissions, err := h.SubmissionRepo.CountByFormID(r.Context(), form.ID, filter)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	counts, err := h.SubmissionRepo.GetAnswerCounts(r.Context(), form.ID, analytics.ReportableFields(formData), filter)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &GetFieldsReportResponse{
+		Report: analytics.BuildFieldsReport(formData, submissions, counts, granularity),
+	})
+}
@@ -43,7 +43,7 @@ func TestRegister_Success(t *testing.T) {
 		},
 	}
 	// inside TestRegister_Success
-	handler := handlers.NewAuthHandler(mockRepo)
+	handler := handlers.NewAuthHandler(mockRepo, nil, nil)
 	wrapped := output.MakeJsonHandler(handler.Register)
 
 	body := map[string]interface{}{
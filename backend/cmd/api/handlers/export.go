@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"formaura/pkg/export"
+	"formaura/pkg/output"
+	"log"
+	"net/http"
+	"slices"
+)
+
+// getExportOptions reads ?format=csv|xlsx|ndjson&layout=flat|steps
+func getExportOptions(r *http.Request) (string, export.Options, error) {
+	params := r.URL.Query()
+
+	format := params.Get("format")
+
+	if format == "" {
+		format = export.FormatCSV
+	}
+
+	if !slices.Contains(export.ValidFormats, format) {
+		return "", export.Options{}, fmt.Errorf("Invalid format value")
+	}
+
+	var opts export.Options
+
+	switch params.Get("layout") {
+	case "", "flat":
+	case "steps":
+		opts.SheetPerStep = true
+	default:
+		return "", export.Options{}, fmt.Errorf("Invalid layout value")
+	}
+
+	return format, opts, nil
+}
+
+// ExportSubmissions streams the form's submissions in the ?format= asked for,
+// it takes the same filters as the submission listing
+func (h *FormHandler) ExportSubmissions(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	format, opts, err := getExportOptions(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	filter, err := getSubmissionFilter(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	filter.SearchLanguage = form.SearchLanguage
+
+	table, err := export.LoadTable(r.Context(), h.FormRepo, form)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to export submissions")
+	}
+
+	exporter, err := export.New(format, w, opts)
+
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("Invalid format value")
+	}
+
+	filename := unsafeFilenameChars.ReplaceAllString(form.Name, "-")
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-submissions.%s"`, filename, exporter.Extension()))
+
+	err = exporter.Begin(table)
+
+	if err == nil {
+		err = h.SubmissionRepo.StreamByFormID(r.Context(), form.ID, *filter, exporter.Write)
+	}
+
+	if closeErr := exporter.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		// the status may have gone out with the first rows, abort the
+		// connection so the client sees a failed download rather than a
+		// truncated file
+		log.Printf("Submission export failed for form %s: %v", form.UUID, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	return output.NilError, nil
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"formaura/pkg/export"
+	"formaura/pkg/exportjob"
+	"formaura/pkg/output"
+	export_repo "formaura/pkg/repositories/export"
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/storage"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+type ExportHandler struct {
+	ExportRepo export_repo.Repository
+	FormRepo   form_repo.Repository
+	storage    storage.Storage
+	signer     *storage.Signer
+}
+
+func NewExportHandler(
+	exportRepo export_repo.Repository,
+	formRepo form_repo.Repository,
+	store storage.Storage,
+	signer *storage.Signer) *ExportHandler {
+	return &ExportHandler{
+		ExportRepo: exportRepo,
+		FormRepo:   formRepo,
+		storage:    store,
+		signer:     signer,
+	}
+}
+
+type ExportJobResponse struct {
+	Job      *export_repo.Model `json:"job"`
+	Progress float64            `json:"progress"`
+	// set once the job is done, the link works without signing in
+	DownloadURL          *string    `json:"download_url"`
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at"`
+}
+
+// CreateExport queues an export of the form's submissions to run in the
+// background, it takes the same params as ExportSubmissions
+func (h *FormHandler) CreateExport(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	format, opts, err := getExportOptions(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	filter, err := getSubmissionFilter(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	job, err := h.ExportRepo.Create(r.Context(), form.ID, usr.ID, format, opts.SheetPerStep, *filter)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to start export")
+	}
+
+	return output.SuccessResponse(w, r, &ExportJobResponse{
+		Job:      job,
+		Progress: job.Progress(),
+	})
+}
+
+// GetExport reports an export's progress, with a short lived download link
+// once it is done
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	jobUuid, err := GetUUIDFromParams(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	job, err := h.ExportRepo.GetByUUID(r.Context(), *jobUuid)
+
+	if err != nil || job.UserID == nil || *job.UserID != usr.ID {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	res := &ExportJobResponse{
+		Job:      job,
+		Progress: job.Progress(),
+	}
+
+	if job.Status == export_repo.StatusDone {
+		url, expires := exportjob.DownloadURL(h.signer, job, time.Now(), exportjob.LinkTTL)
+		res.DownloadURL = &url
+		res.DownloadURLExpiresAt = &expires
+	}
+
+	return output.SuccessResponse(w, r, res)
+}
+
+// DownloadExport serves a finished export's file to anyone holding a signed
+// link, the form must still exist and not be in the trash
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) (int, error) {
+	jobUuid, err := GetUUIDFromParams(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	err = h.signer.Verify(exportjob.DownloadPath(*jobUuid), r.URL.Query(), time.Now())
+
+	if errors.Is(err, storage.ErrLinkExpired) {
+		return http.StatusGone, fmt.Errorf("Download link has expired")
+	}
+
+	if err != nil {
+		return http.StatusForbidden, fmt.Errorf("Invalid download link")
+	}
+
+	job, err := h.ExportRepo.GetByUUID(r.Context(), *jobUuid)
+
+	if err != nil || job.Status != export_repo.StatusDone || job.StorageKey == nil || job.FormID == nil {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return http.StatusGone, fmt.Errorf("Export has expired")
+	}
+
+	form, err := h.FormRepo.GetByID(r.Context(), *job.FormID)
+
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	// only used for the format's content type and extension
+	exporter, err := export.New(job.Format, io.Discard, export.Options{})
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to download export")
+	}
+
+	file, err := h.storage.Open(r.Context(), *job.StorageKey)
+
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+	defer file.Close()
+
+	filename := unsafeFilenameChars.ReplaceAllString(form.Name, "-")
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-submissions.%s"`, filename, exporter.Extension()))
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Export download failed for job %s: %v", job.UUID, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	return output.NilError, nil
+}
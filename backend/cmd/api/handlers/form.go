@@ -1,34 +1,98 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	user_memory_cache "formaura/pkg/cache/user_memory"
 	"formaura/pkg/email"
+	"formaura/pkg/jsonpatch"
 	"formaura/pkg/output"
+	event_repo "formaura/pkg/repositories/event"
+	export_repo "formaura/pkg/repositories/export"
 	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	template_repo "formaura/pkg/repositories/template"
+	user_repo "formaura/pkg/repositories/user"
 	"formaura/pkg/validate"
 	"net/http"
+	"slices"
+	"time"
 )
 
 type FormHandler struct {
-	FormRepo    form_repo.Repository
-	authCache   *user_memory_cache.Cache
-	emailClient *email.Client
+	FormRepo       form_repo.Repository
+	TemplateRepo   template_repo.Repository
+	EventRepo      event_repo.Repository
+	SubmissionRepo submission_repo.Repository
+	ExportRepo     export_repo.Repository
+	authCache      *user_memory_cache.Cache
+	emailClient    *email.Client
+	trashRetention time.Duration
 }
 
 func NewFormHandler(
 	repo form_repo.Repository,
+	templateRepo template_repo.Repository,
+	eventRepo event_repo.Repository,
+	submissionRepo submission_repo.Repository,
+	exportRepo export_repo.Repository,
 	authCache *user_memory_cache.Cache,
-	emailClient *email.Client) *FormHandler {
+	emailClient *email.Client,
+	trashRetention time.Duration) *FormHandler {
 	return &FormHandler{
-		FormRepo:    repo,
-		authCache:   authCache,
-		emailClient: emailClient,
+		FormRepo:       repo,
+		TemplateRepo:   templateRepo,
+		EventRepo:      eventRepo,
+		SubmissionRepo: submissionRepo,
+		ExportRepo:     exportRepo,
+		authCache:      authCache,
+		emailClient:    emailClient,
+		trashRetention: trashRetention,
 	}
 }
 
-type GetListingResponse struct {
-	Forms *[]*form_repo.FormModel `json:"forms"`
+// getUserForm loads the form in the uuid route param and checks usr owns it
+func (h *FormHandler) getUserForm(r *http.Request, usr *user_repo.Model) (*form_repo.FormModel, int, error) {
+	formUuid, err := GetUUIDFromParams(r)
+
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	form, err := h.FormRepo.GetByUUID(r.Context(), *formUuid)
+
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	if form.UserID != usr.ID {
+		return nil, http.StatusForbidden, fmt.Errorf("Resource not found")
+	}
+
+	return form, 0, nil
+}
+
+type StaleFormResponse struct {
+	Message string               `json:"message"`
+	Form    *form_repo.FormModel `json:"form"`
+}
+
+// staleFormResponse answers an update made against an old revision with the
+// current server state so the client can merge or reload
+func (h *FormHandler) staleFormResponse(w http.ResponseWriter, r *http.Request, form *form_repo.FormModel) (int, error) {
+	current, err := h.FormRepo.GetByID(r.Context(), form.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	w.Header().Set("ETag", current.ETag())
+
+	return output.ErrorResponse(w, r, http.StatusConflict, &StaleFormResponse{
+		Message: "This form has been changed since you loaded it",
+		Form:    current,
+	})
 }
 
 type GetFormResponse struct {
@@ -42,15 +106,67 @@ func (h *FormHandler) GetDetailedListing(w http.ResponseWriter, r *http.Request)
 		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
 	}
 
-	listing, err := h.FormRepo.GetDetailedListingByUserID(r.Context(), usr.ID)
+	query, err := getListingQuery(r)
 
 	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	listing, err := h.FormRepo.GetDetailedListingByUserID(r.Context(), usr.ID, *query)
+
+	if err != nil {
+		if errors.Is(err, form_repo.ErrInvalidCursor) {
+			return http.StatusBadRequest, fmt.Errorf("Cursor is invalid")
+		}
 		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
 	}
 
-	return output.SuccessResponse(w, r, &GetListingResponse{
-		Forms: &listing,
-	})
+	return output.SuccessResponse(w, r, listing)
+}
+
+// getListingQuery reads ?status=&q=&from=&to=&sort=&order=&cursor=&limit=
+func getListingQuery(r *http.Request) (*form_repo.ListingQuery, error) {
+	params := r.URL.Query()
+
+	query := &form_repo.ListingQuery{
+		Status: params.Get("status"),
+		Search: params.Get("q"),
+		Sort:   params.Get("sort"),
+		Cursor: params.Get("cursor"),
+	}
+
+	if query.Status != "" && !validate.IsValidStatus(query.Status) {
+		return nil, fmt.Errorf("Invalid status value")
+	}
+
+	if query.Sort != "" && !slices.Contains(form_repo.ValidSorts, query.Sort) {
+		return nil, fmt.Errorf("Invalid sort value")
+	}
+
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Asc = true
+	default:
+		return nil, fmt.Errorf("Invalid order value")
+	}
+
+	if params.Get("limit") != "" {
+		limit, err := GetIntFromQuery(r, "limit")
+		if err != nil {
+			return nil, err
+		}
+		query.Limit = limit
+	}
+
+	from, before, err := GetDateRangeFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+	query.CreatedFrom = from
+	query.CreatedBefore = before
+
+	return query, nil
 }
 
 func (h *FormHandler) NewForm(w http.ResponseWriter, r *http.Request) (int, error) {
@@ -70,11 +186,30 @@ func (h *FormHandler) NewForm(w http.ResponseWriter, r *http.Request) (int, erro
 
 	newTitle := form_repo.GenerateFormUntitledName(listing)
 
-	blankForm := form_repo.FormData{
+	formData := &form_repo.FormData{
 		Steps: []form_repo.Step{},
 	}
 
-	newForm, err := h.FormRepo.Create(r.Context(), usr.ID, newTitle, nil, blankForm)
+	var description *string
+
+	if key := r.URL.Query().Get("template"); key != "" {
+		tmpl, status, err := h.resolveTemplate(r, usr, key)
+
+		if err != nil {
+			return status, err
+		}
+
+		formData, err = form_repo.CloneWithNewUUIDs(&tmpl.FormData)
+
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Unable to create a new form")
+		}
+
+		newTitle = form_repo.GenerateFormNumberedName(tmpl.Name, listing)
+		description = optionalString(tmpl.Description)
+	}
+
+	newForm, err := h.FormRepo.Create(r.Context(), usr.ID, newTitle, description, *formData)
 
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("FormRepo.Create: Unable to create a new form")
@@ -85,6 +220,57 @@ func (h *FormHandler) NewForm(w http.ResponseWriter, r *http.Request) (int, erro
 	})
 }
 
+func (h *FormHandler) DuplicateForm(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	var formData form_repo.FormData
+
+	if err := form.UnmarshalFormData(&formData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to duplicate form")
+	}
+
+	clone, err := form_repo.CloneWithNewUUIDs(&formData)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to duplicate form")
+	}
+
+	listing, err := h.FormRepo.GetBasicListingByUserID(r.Context(), usr.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to duplicate form")
+	}
+
+	newName := form_repo.GenerateFormCopyName(form.Name, listing)
+	withAffiliates := r.URL.Query().Get("affiliates") == "true"
+
+	duplicate, err := h.FormRepo.Duplicate(r.Context(), form.ID, usr.ID, newName, *clone, withAffiliates)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to duplicate form")
+	}
+
+	created, err := h.FormRepo.GetByID(r.Context(), duplicate.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to duplicate form")
+	}
+
+	return output.SuccessResponse(w, r, &GetFormResponse{
+		Form: created,
+	})
+}
+
 func (h *FormHandler) GetForm(w http.ResponseWriter, r *http.Request) (int, error) {
 	usr, err := GetUserFromCtx(r)
 
@@ -108,20 +294,200 @@ func (h *FormHandler) GetForm(w http.ResponseWriter, r *http.Request) (int, erro
 		return http.StatusForbidden, fmt.Errorf("Resource not found")
 	}
 
+	w.Header().Set("ETag", form.ETag())
+
 	return output.SuccessResponse(w, r, &GetFormResponse{
 		Form: form,
 	})
 }
 
+type UpdateFormDataReqBody struct {
+	FormData *form_repo.FormData `json:"form_data"`
+}
+
+func (r *UpdateFormDataReqBody) validate() error {
+	if r.FormData == nil {
+		return fmt.Errorf("Request body invalid")
+	}
+
+	if r.FormData.Steps == nil {
+		r.FormData.Steps = []form_repo.Step{}
+	}
+
+	return nil
+}
+
+type UpdateFormDataResponse struct {
+	Form   *form_repo.FormModel `json:"form"`
+	Issues validate.Issues      `json:"issues"`
+}
+
+type FormDataErrorResponse struct {
+	Message string          `json:"message"`
+	Issues  validate.Issues `json:"issues"`
+}
+
 func (h *FormHandler) UpdateFormData(w http.ResponseWriter, r *http.Request) (int, error) {
+	defer r.Body.Close()
+
 	usr, err := GetUserFromCtx(r)
 
 	if err != nil {
 		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
 	}
 
-	return output.SuccessResponse(w, r, &AutoAuthResp{
-		User: usr,
+	var body UpdateFormDataReqBody
+
+	if err := DecodeBody(r, &body); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if err := body.validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	revision, err := GetIfMatchRevision(r)
+
+	if err != nil {
+		return http.StatusPreconditionRequired, err
+	}
+
+	if form.Revision != revision {
+		return h.staleFormResponse(w, r, form)
+	}
+
+	issues := validate.LintFormData(body.FormData)
+
+	if issues.HasErrors() {
+		return output.ErrorResponse(w, r, http.StatusUnprocessableEntity, &FormDataErrorResponse{
+			Message: "Form data invalid",
+			Issues:  issues,
+		})
+	}
+
+	updated, err := h.FormRepo.UpdateFormData(r.Context(), form.ID, revision, *body.FormData)
+
+	if errors.Is(err, form_repo.ErrStaleRevision) {
+		return h.staleFormResponse(w, r, form)
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to save form")
+	}
+
+	w.Header().Set("ETag", updated.ETag())
+
+	return output.SuccessResponse(w, r, &UpdateFormDataResponse{
+		Form:   updated,
+		Issues: issues,
+	})
+}
+
+var errFormDataInvalid = errors.New("Form data invalid")
+
+// patchError is a patch that cannot be applied to the form data, the only
+// error from saving a patch that is reported to the client
+type patchError struct {
+	err error
+}
+
+func (e *patchError) Error() string {
+	return e.err.Error()
+}
+
+func (e *patchError) Unwrap() error {
+	return e.err
+}
+
+func (h *FormHandler) PatchFormData(w http.ResponseWriter, r *http.Request) (int, error) {
+	defer r.Body.Close()
+
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	var patch jsonpatch.Patch
+
+	if err := DecodeBody(r, &patch); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if len(patch) == 0 {
+		return http.StatusBadRequest, fmt.Errorf("Request body invalid")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	revision, err := GetIfMatchRevision(r)
+
+	if err != nil {
+		return http.StatusPreconditionRequired, err
+	}
+
+	if form.Revision != revision {
+		return h.staleFormResponse(w, r, form)
+	}
+
+	var issues validate.Issues
+
+	updated, err := h.FormRepo.PatchFormData(r.Context(), form.ID, revision, func(current json.RawMessage) (*form_repo.FormData, error) {
+		patched, err := jsonpatch.Apply(current, patch)
+		if err != nil {
+			return nil, &patchError{err}
+		}
+
+		var formData form_repo.FormData
+		if err := json.Unmarshal(patched, &formData); err != nil {
+			return nil, &patchError{fmt.Errorf("Patched form data is not a valid form: %w", err)}
+		}
+
+		if formData.Steps == nil {
+			formData.Steps = []form_repo.Step{}
+		}
+
+		issues = validate.LintFormData(&formData)
+		if issues.HasErrors() {
+			return nil, errFormDataInvalid
+		}
+
+		return &formData, nil
+	})
+
+	var invalid *patchError
+
+	switch {
+	case errors.Is(err, form_repo.ErrStaleRevision):
+		return h.staleFormResponse(w, r, form)
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return http.StatusConflict, err
+	case errors.Is(err, errFormDataInvalid):
+		return output.ErrorResponse(w, r, http.StatusUnprocessableEntity, &FormDataErrorResponse{
+			Message: "Form data invalid",
+			Issues:  issues,
+		})
+	case errors.As(err, &invalid):
+		return http.StatusUnprocessableEntity, invalid
+	case err != nil:
+		return http.StatusInternalServerError, fmt.Errorf("Unable to save form")
+	}
+
+	w.Header().Set("ETag", updated.ETag())
+
+	return output.SuccessResponse(w, r, &UpdateFormDataResponse{
+		Form:   updated,
+		Issues: issues,
 	})
 }
 
@@ -160,28 +526,34 @@ func (h *FormHandler) UpdateFormMeta(w http.ResponseWriter, r *http.Request) (in
 		return http.StatusBadRequest, err
 	}
 
-	formUuid, err := GetUUIDFromParams(r)
+	form, status, err := h.getUserForm(r, usr)
 
 	if err != nil {
-		return http.StatusBadRequest, err
+		return status, err
 	}
 
-	form, err := h.FormRepo.GetByUUID(r.Context(), *formUuid)
+	revision, err := GetIfMatchRevision(r)
 
 	if err != nil {
-		return http.StatusNotFound, fmt.Errorf("Resource not found")
+		return http.StatusPreconditionRequired, err
 	}
 
-	if form.UserID != usr.ID {
-		return http.StatusForbidden, fmt.Errorf("Resource not found")
+	if form.Revision != revision {
+		return h.staleFormResponse(w, r, form)
 	}
 
-	updated, err := h.FormRepo.UpdateFormMeta(r.Context(), form.ID, body.Name, body.Description, body.Status)
+	updated, err := h.FormRepo.UpdateFormMeta(r.Context(), form.ID, revision, body.Name, body.Description, body.Status)
+
+	if errors.Is(err, form_repo.ErrStaleRevision) {
+		return h.staleFormResponse(w, r, form)
+	}
 
 	if err != nil {
 		return http.StatusForbidden, fmt.Errorf("Resource not found")
 	}
 
+	w.Header().Set("ETag", updated.ETag())
+
 	return output.SuccessResponse(w, r, &GetFormResponse{
 		Form: updated,
 	})
@@ -206,7 +578,118 @@ func (h *FormHandler) DeleteForm(w http.ResponseWriter, r *http.Request) (int, e
 		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
 	}
 
-	return output.SuccessResponse(w, r, &AutoAuthResp{
-		User: usr,
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	err = h.FormRepo.SoftDelete(r.Context(), form.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to delete form")
+	}
+
+	return output.SuccessResponse(w, r, &output.MessageResponse{
+		Message: "Form moved to trash",
+	})
+}
+
+type GetTrashResponse struct {
+	Forms         []*form_repo.FormModel `json:"forms"`
+	RetentionDays int                    `json:"retention_days"`
+}
+
+func (h *FormHandler) GetTrash(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	forms, err := h.FormRepo.GetTrashListingByUserID(r.Context(), usr.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &GetTrashResponse{
+		Forms:         forms,
+		RetentionDays: int(h.trashRetention.Hours() / 24),
+	})
+}
+
+// getUserTrashedForm is getUserForm for forms that are in the trash
+func (h *FormHandler) getUserTrashedForm(r *http.Request, usr *user_repo.Model) (*form_repo.FormModel, int, error) {
+	formUuid, err := GetUUIDFromParams(r)
+
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	form, err := h.FormRepo.GetTrashedByUUID(r.Context(), *formUuid)
+
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	if form.UserID != usr.ID {
+		return nil, http.StatusForbidden, fmt.Errorf("Resource not found")
+	}
+
+	return form, 0, nil
+}
+
+func (h *FormHandler) RestoreForm(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserTrashedForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	_, err = h.FormRepo.RestoreFromTrash(r.Context(), form.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to restore form")
+	}
+
+	restored, err := h.FormRepo.GetByID(r.Context(), form.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to restore form")
+	}
+
+	return output.SuccessResponse(w, r, &GetFormResponse{
+		Form: restored,
+	})
+}
+
+func (h *FormHandler) PurgeForm(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserTrashedForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	err = h.FormRepo.Delete(r.Context(), form.UUID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to delete form")
+	}
+
+	return output.SuccessResponse(w, r, &output.MessageResponse{
+		Message: "Form permanently deleted",
 	})
 }
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"formaura/pkg/output"
+	submission_repo "formaura/pkg/repositories/submission"
+	"formaura/pkg/validate"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type GetSubmissionResponse struct {
+	Submission *submission_repo.Model `json:"submission"`
+}
+
+// getSubmissionFilter reads ?q=&from=&to=&affiliate=&email=&field[<uuid>]=
+func getSubmissionFilter(r *http.Request) (*submission_repo.Filter, error) {
+	params := r.URL.Query()
+
+	filter := &submission_repo.Filter{
+		Email:  params.Get("email"),
+		Search: params.Get("q"),
+	}
+
+	from, before, err := GetDateRangeFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+	filter.From = from
+	filter.Before = before
+
+	if affiliate := params.Get("affiliate"); affiliate != "" {
+		if !validate.ValidateUUID(affiliate) {
+			return nil, fmt.Errorf("Incorrect affiliate uuid format")
+		}
+		filter.AffiliateUUID = &affiliate
+	}
+
+	for key, values := range params {
+		if !strings.HasPrefix(key, "field[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+
+		fieldUUID := key[len("field[") : len(key)-1]
+
+		if !validate.ValidateUUID(fieldUUID) {
+			return nil, fmt.Errorf("Incorrect field uuid format")
+		}
+
+		if filter.Fields == nil {
+			filter.Fields = map[string]string{}
+		}
+		filter.Fields[fieldUUID] = values[0]
+	}
+
+	return filter, nil
+}
+
+// getSubmissionListingQuery reads the filter along with ?sort=&order=&cursor=&limit=
+func getSubmissionListingQuery(r *http.Request) (*submission_repo.ListingQuery, error) {
+	params := r.URL.Query()
+
+	filter, err := getSubmissionFilter(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &submission_repo.ListingQuery{
+		Filter: *filter,
+		Sort:   params.Get("sort"),
+		Cursor: params.Get("cursor"),
+	}
+
+	if query.Sort != "" && !slices.Contains(submission_repo.ValidSorts, query.Sort) {
+		return nil, fmt.Errorf("Invalid sort value")
+	}
+
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Asc = true
+	default:
+		return nil, fmt.Errorf("Invalid order value")
+	}
+
+	if params.Get("limit") != "" {
+		limit, err := GetIntFromQuery(r, "limit")
+		if err != nil {
+			return nil, err
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// GetSubmissions pages through a form's submissions, with ?q= the results are
+// full text matches ranked best first and carry a highlighted headline
+func (h *FormHandler) GetSubmissions(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	query, err := getSubmissionListingQuery(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	query.SearchLanguage = form.SearchLanguage
+
+	listing, err := h.SubmissionRepo.GetPageByFormID(r.Context(), form.ID, *query)
+
+	if err != nil {
+		if errors.Is(err, submission_repo.ErrInvalidCursor) {
+			return http.StatusBadRequest, fmt.Errorf("Cursor is invalid")
+		}
+		if errors.Is(err, submission_repo.ErrSearchRequired) {
+			return http.StatusBadRequest, fmt.Errorf("Sorting by relevance requires a search")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, listing)
+}
+
+// GetSubmission serves one submission of the form in the uuid route param,
+// a submission that belongs to another form is not found
+func (h *FormHandler) GetSubmission(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	submissionUUID := mux.Vars(r)["submission_uuid"]
+
+	if !validate.ValidateUUID(submissionUUID) {
+		return http.StatusBadRequest, fmt.Errorf("Incorrect submission uuid format")
+	}
+
+	submission, err := h.SubmissionRepo.GetByUUID(r.Context(), submissionUUID)
+
+	if err != nil || submission.FormID != form.ID {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	return output.SuccessResponse(w, r, &GetSubmissionResponse{
+		Submission: submission,
+	})
+}
+
+type UpdateSearchLanguageReqBody struct {
+	SearchLanguage string `json:"search_language"`
+}
+
+// UpdateSearchLanguage sets the language the form's submissions are stemmed
+// in for search, existing submissions are reindexed
+func (h *FormHandler) UpdateSearchLanguage(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	var body UpdateSearchLanguageReqBody
+
+	if err := DecodeBody(r, &body); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if !validate.IsValidSearchLanguage(body.SearchLanguage) {
+		return http.StatusBadRequest, fmt.Errorf("Invalid search language value")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	updated, err := h.FormRepo.UpdateSearchLanguage(r.Context(), form.ID, body.SearchLanguage)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &GetFormResponse{
+		Form: updated,
+	})
+}
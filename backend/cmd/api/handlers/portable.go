@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"formaura/pkg/output"
+	"formaura/pkg/portable"
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/validate"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// imports are a single form definition, anything bigger is not a form
+const maxImportBytes = 5 << 20
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+type ImportFormResponse struct {
+	Form   *form_repo.FormModel `json:"form"`
+	Issues validate.Issues      `json:"issues"`
+}
+
+func (h *FormHandler) ExportForm(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	doc, err := portable.Export(form)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to export form")
+	}
+
+	filename := unsafeFilenameChars.ReplaceAllString(form.Name, "-")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.formaura.json"`, filename))
+
+	return output.SuccessResponse(w, r, doc)
+}
+
+func (h *FormHandler) ImportForm(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+
+	if err != nil {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("Import is too large")
+	}
+
+	doc, err := portable.Decode(body)
+
+	if err != nil {
+		if errors.Is(err, portable.ErrUnsupported) {
+			return http.StatusUnprocessableEntity, fmt.Errorf("Unsupported schema version")
+		}
+		return http.StatusBadRequest, fmt.Errorf("Invalid form document")
+	}
+
+	formData, issues, err := portable.Prepare(doc)
+
+	if err != nil {
+		var invalid *portable.InvalidFormDataError
+		if errors.As(err, &invalid) {
+			return output.ErrorResponse(w, r, http.StatusUnprocessableEntity, &FormDataErrorResponse{
+				Message: "Form data invalid",
+				Issues:  invalid.Issues,
+			})
+		}
+		return http.StatusInternalServerError, fmt.Errorf("Unable to import form")
+	}
+
+	listing, err := h.FormRepo.GetBasicListingByUserID(r.Context(), usr.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to import form")
+	}
+
+	name := form_repo.GenerateFormNumberedName(doc.Form.Name, listing)
+
+	// imports always land as a draft, publishing is a separate decision
+	newForm, err := h.FormRepo.Create(r.Context(), usr.ID, name, doc.Form.Description, *formData)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to import form")
+	}
+
+	return output.SuccessResponse(w, r, &ImportFormResponse{
+		Form:   newForm,
+		Issues: issues,
+	})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"formaura/pkg/output"
+	form_repo "formaura/pkg/repositories/form"
+	"net/http"
+)
+
+type GetRevisionsResponse struct {
+	Revisions []*form_repo.RevisionModel `json:"revisions"`
+}
+
+type GetRevisionResponse struct {
+	Revision *form_repo.RevisionModel `json:"revision"`
+}
+
+type RevisionDiffResponse struct {
+	From     int                     `json:"from"`
+	To       int                     `json:"to"`
+	Meta     []string                `json:"meta"`
+	FormData *form_repo.FormDataDiff `json:"form_data"`
+}
+
+func (h *FormHandler) GetRevisions(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	revisions, err := h.FormRepo.GetRevisionsByFormID(r.Context(), form.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &GetRevisionsResponse{
+		Revisions: revisions,
+	})
+}
+
+func (h *FormHandler) GetRevision(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	revision, err := GetIntFromParams(r, "revision")
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	rev, err := h.FormRepo.GetRevision(r.Context(), form.ID, revision)
+
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	return output.SuccessResponse(w, r, &GetRevisionResponse{
+		Revision: rev,
+	})
+}
+
+func (h *FormHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	from, err := GetIntFromQuery(r, "from")
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	to, err := GetIntFromQuery(r, "to")
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	fromRev, err := h.FormRepo.GetRevision(r.Context(), form.ID, from)
+
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Revision %d not found", from)
+	}
+
+	toRev, err := h.FormRepo.GetRevision(r.Context(), form.ID, to)
+
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Revision %d not found", to)
+	}
+
+	var fromData, toData form_repo.FormData
+
+	if err := fromRev.UnmarshalFormData(&fromData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	if err := toRev.UnmarshalFormData(&toData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	meta := []string{}
+
+	if fromRev.Name != toRev.Name {
+		meta = append(meta, "name")
+	}
+
+	if derefString(fromRev.Description) != derefString(toRev.Description) {
+		meta = append(meta, "description")
+	}
+
+	if fromRev.Status != toRev.Status {
+		meta = append(meta, "status")
+	}
+
+	return output.SuccessResponse(w, r, &RevisionDiffResponse{
+		From:     from,
+		To:       to,
+		Meta:     meta,
+		FormData: form_repo.DiffFormData(&fromData, &toData),
+	})
+}
+
+func (h *FormHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	revision, err := GetIntFromParams(r, "revision")
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	expected, err := GetIfMatchRevision(r)
+
+	if err != nil {
+		return http.StatusPreconditionRequired, err
+	}
+
+	if form.Revision != expected {
+		return h.staleFormResponse(w, r, form)
+	}
+
+	if _, err := h.FormRepo.GetRevision(r.Context(), form.ID, revision); err != nil {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	restored, err := h.FormRepo.RestoreRevision(r.Context(), form.ID, expected, revision)
+
+	if errors.Is(err, form_repo.ErrStaleRevision) {
+		return h.staleFormResponse(w, r, form)
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to restore revision")
+	}
+
+	w.Header().Set("ETag", restored.ETag())
+
+	return output.SuccessResponse(w, r, &GetFormResponse{
+		Form: restored,
+	})
+}
@@ -1,51 +1,102 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"formaura/pkg/email"
 	"formaura/pkg/output"
+	"formaura/pkg/ratelimit"
+	event_repo "formaura/pkg/repositories/event"
 	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
 	"formaura/pkg/validate"
+	"formaura/pkg/views"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 )
 
 type SubmissionHandler struct {
-	FormRepo    form_repo.Repository
-	emailClient *email.Client
+	FormRepo       form_repo.Repository
+	SubmissionRepo submission_repo.Repository
+	EventRepo      event_repo.Repository
+	viewCounter    *views.Counter
+	emailClient    *email.Client
+	eventLimiter   *ratelimit.Limiter
 }
 
+// eventsPerMinute is how many events one address may report, enough for a
+// long form to be filled in by several people behind the same address
+const eventsPerMinute = 120
+
 func NewSubmissionHandler(
-	repo form_repo.Repository,
+	formRepo form_repo.Repository,
+	submissionRepo submission_repo.Repository,
+	eventRepo event_repo.Repository,
+	viewCounter *views.Counter,
 	emailClient *email.Client) *SubmissionHandler {
 	return &SubmissionHandler{
-		FormRepo:    repo,
-		emailClient: emailClient,
+		FormRepo:       formRepo,
+		SubmissionRepo: submissionRepo,
+		EventRepo:      eventRepo,
+		viewCounter:    viewCounter,
+		emailClient:    emailClient,
+		eventLimiter:   ratelimit.New(eventsPerMinute, time.Minute),
 	}
 }
 
-func (h *SubmissionHandler) GetForm(w http.ResponseWriter, r *http.Request) (int, error) {
+type SubmitFormResponse struct {
+	Submission *submission_repo.Model `json:"submission"`
+}
 
+type SubmissionErrorResponse struct {
+	Message string               `json:"message"`
+	Errors  validate.FieldErrors `json:"errors"`
+}
+
+// getPublishedForm loads the form in the uuid route param with its published
+// version applied, drafts and inactive forms are never served publicly
+func (h *SubmissionHandler) getPublishedForm(r *http.Request) (*form_repo.FormModel, *form_repo.VersionModel, int, error) {
 	formUuid, err := GetUUIDFromParams(r)
 
 	if err != nil {
-		return http.StatusBadRequest, err
+		return nil, nil, http.StatusBadRequest, err
 	}
 
 	form, err := h.FormRepo.GetByUUID(r.Context(), *formUuid)
 
 	if err != nil {
-		return http.StatusNotFound, fmt.Errorf("Resource not found")
+		return nil, nil, http.StatusNotFound, fmt.Errorf("Resource not found")
 	}
 
-	if form.Status != form_repo.StatusActive {
-		return http.StatusForbidden, fmt.Errorf("This form is currently unavailable")
+	if form.Status != form_repo.StatusActive || form.PublishedVersionID == nil {
+		return nil, nil, http.StatusForbidden, fmt.Errorf("This form is currently unavailable")
 	}
 
-	err = h.FormRepo.IncrementViews(context.Background(), *formUuid)
+	version, err := h.FormRepo.GetVersionByID(r.Context(), *form.PublishedVersionID)
+
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("Unable to view form, please try again later")
+	}
+
+	form.ApplyVersion(version)
+
+	return form, version, 0, nil
+}
+
+func (h *SubmissionHandler) GetForm(w http.ResponseWriter, r *http.Request) (int, error) {
+
+	form, _, status, err := h.getPublishedForm(r)
 
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("Unable to view form, please try again later")
+		return status, err
+	}
+
+	// the owner previewing their own form is not a view, the public route
+	// only has a user when an auth token was sent
+	if usr, err := GetUserFromCtx(r); err != nil || usr.ID != form.UserID {
+		// buffered and written in batches, see views.Counter
+		h.viewCounter.Add(form.ID, GetClientIP(r), r.UserAgent())
 	}
 
 	return output.SuccessResponse(w, r, &GetFormResponse{
@@ -54,7 +105,10 @@ func (h *SubmissionHandler) GetForm(w http.ResponseWriter, r *http.Request) (int
 }
 
 type SubmitFormReqBody struct {
-	AffiliateUUID string `json:"affiliate_uuid"`
+	AffiliateUUID string         `json:"affiliate_uuid"`
+	FullName      string         `json:"full_name"`
+	Email         string         `json:"email"`
+	Data          map[string]any `json:"submission_data"`
 }
 
 func (r *SubmitFormReqBody) validate() error {
@@ -64,36 +118,204 @@ func (r *SubmitFormReqBody) validate() error {
 		}
 	}
 
+	if validate.StrNotEmpty(r.Email) {
+		if !validate.IsEmail(r.Email) {
+			return fmt.Errorf("Incorrect email format")
+		}
+	}
+
+	if r.Data == nil {
+		return fmt.Errorf("Request body invalid")
+	}
+
 	return nil
 }
 
 func (h *SubmissionHandler) SubmitForm(w http.ResponseWriter, r *http.Request) (int, error) {
+	defer r.Body.Close()
 
-	formUuid, err := GetUUIDFromParams(r)
+	form, version, status, err := h.getPublishedForm(r)
 
 	if err != nil {
+		return status, err
+	}
+
+	var body SubmitFormReqBody
+
+	if err := DecodeBody(r, &body); err != nil {
 		return http.StatusBadRequest, err
 	}
 
-	form, err := h.FormRepo.GetByUUID(r.Context(), *formUuid)
+	body.FullName = strings.TrimSpace(body.FullName)
+	body.Email = strings.TrimSpace(body.Email)
+
+	if err := body.validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	var formData form_repo.FormData
+
+	if err := version.UnmarshalFormData(&formData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to submit form, please try again later")
+	}
+
+	answers, fieldErrs := validate.ValidateSubmission(&formData, body.Data)
+
+	if len(fieldErrs) > 0 {
+		return output.ErrorResponse(w, r, http.StatusUnprocessableEntity, &SubmissionErrorResponse{
+			Message: "Submission invalid",
+			Errors:  fieldErrs,
+		})
+	}
+
+	var affiliateUUID *string
+
+	if validate.StrNotEmpty(body.AffiliateUUID) {
+		affiliates, err := form.GetAffiliates()
+
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Unable to submit form, please try again later")
+		}
+
+		isFormAffiliate := slices.ContainsFunc(affiliates, func(a form_repo.AffiliateInfo) bool {
+			return strings.EqualFold(a.UUID, body.AffiliateUUID)
+		})
+
+		if !isFormAffiliate {
+			return http.StatusBadRequest, fmt.Errorf("Affiliate not found for this form")
+		}
+
+		affiliateUUID = &body.AffiliateUUID
+	}
+
+	submission, err := h.SubmissionRepo.Create(
+		r.Context(),
+		form.ID,
+		version.ID,
+		affiliateUUID,
+		optionalString(body.FullName),
+		optionalString(body.Email),
+		answers,
+	)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to submit form, please try again later")
+	}
+
+	return output.SuccessResponse(w, r, &SubmitFormResponse{
+		Submission: submission,
+	})
+}
+
+// GetSubmission serves one stored submission to the owner of its form
+func (h *SubmissionHandler) GetSubmission(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	submissionUuid, err := GetUUIDFromParams(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	submission, err := h.SubmissionRepo.GetByUUID(r.Context(), *submissionUuid)
 
 	if err != nil {
 		return http.StatusNotFound, fmt.Errorf("Resource not found")
 	}
 
-	var body SubmitFormReqBody
+	form, err := h.FormRepo.GetByID(r.Context(), submission.FormID)
+
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Resource not found")
+	}
+
+	if form.UserID != usr.ID {
+		return http.StatusForbidden, fmt.Errorf("Resource not found")
+	}
+
+	return output.SuccessResponse(w, r, &GetSubmissionResponse{
+		Submission: submission,
+	})
+}
+
+type TrackEventReqBody struct {
+	SessionID string `json:"session_id"`
+	Type      string `json:"type"`
+	StepUUID  string `json:"step_uuid"`
+}
+
+func (r *TrackEventReqBody) validate(formData *form_repo.FormData) error {
+	if !validate.ValidateUUID(r.SessionID) {
+		return fmt.Errorf("Incorrect session id format")
+	}
+
+	if !slices.Contains(event_repo.ValidTypes, r.Type) {
+		return fmt.Errorf("Invalid event type")
+	}
+
+	if r.Type == event_repo.TypeFormStarted {
+		r.StepUUID = ""
+		return nil
+	}
+
+	isFormStep := slices.ContainsFunc(formData.Steps, func(s form_repo.Step) bool {
+		return s.UUID == r.StepUUID
+	})
+
+	if !isFormStep {
+		return fmt.Errorf("Step not found")
+	}
+
+	return nil
+}
+
+// TrackEvent records the renderer's progress through a form for the funnel
+// report. Bots and the owner previewing their form are accepted but not recorded.
+func (h *SubmissionHandler) TrackEvent(w http.ResponseWriter, r *http.Request) (int, error) {
+	defer r.Body.Close()
+
+	if !h.eventLimiter.Allow(GetClientIP(r), time.Now()) {
+		return http.StatusTooManyRequests, fmt.Errorf("Too many requests")
+	}
+
+	form, version, status, err := h.getPublishedForm(r)
+
+	if err != nil {
+		return status, err
+	}
+
+	var body TrackEventReqBody
 
 	if err := DecodeBody(r, &body); err != nil {
 		return http.StatusBadRequest, err
 	}
 
-	if err := body.validate(); err != nil {
+	var formData form_repo.FormData
+
+	if err := version.UnmarshalFormData(&formData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	if err := body.validate(&formData); err != nil {
 		return http.StatusBadRequest, err
 	}
 
-	//TODO: Fill out rest of this handler, create a submission etc
+	usr, _ := GetUserFromCtx(r)
+	isOwner := usr != nil && usr.ID == form.UserID
 
-	return output.SuccessResponse(w, r, &GetFormResponse{
-		Form: form,
+	if !isOwner && !views.IsBot(r.UserAgent()) {
+		err = h.EventRepo.Create(r.Context(), form.ID, version.ID, body.SessionID, body.Type, optionalString(body.StepUUID))
+
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Unable to record event")
+		}
+	}
+
+	return output.SuccessResponse(w, r, &output.MessageResponse{
+		Message: "Event recorded",
 	})
 }
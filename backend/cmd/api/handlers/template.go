@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"formaura/pkg/output"
+	form_repo "formaura/pkg/repositories/form"
+	template_repo "formaura/pkg/repositories/template"
+	user_repo "formaura/pkg/repositories/user"
+	"formaura/pkg/templates"
+	"formaura/pkg/validate"
+	"net/http"
+)
+
+type GetTemplatesResponse struct {
+	Templates []*templates.Template `json:"templates"`
+}
+
+type SaveTemplateResponse struct {
+	Template *templates.Template `json:"template"`
+}
+
+func privateTemplate(model *template_repo.Model) (*templates.Template, error) {
+	tmpl := &templates.Template{
+		Key:         model.UUID,
+		Name:        model.Name,
+		Description: derefString(model.Description),
+	}
+
+	if err := model.UnmarshalFormData(&tmpl.FormData); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// resolveTemplate looks up a built-in template by key, falling back to one of
+// the user's private templates by uuid
+func (h *FormHandler) resolveTemplate(r *http.Request, usr *user_repo.Model, key string) (*templates.Template, int, error) {
+	if tmpl := templates.Get(key); tmpl != nil {
+		return tmpl, 0, nil
+	}
+
+	model, err := h.TemplateRepo.GetByUUID(r.Context(), key)
+
+	if err != nil || model.UserID != usr.ID {
+		return nil, http.StatusNotFound, fmt.Errorf("Template not found")
+	}
+
+	tmpl, err := privateTemplate(model)
+
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return tmpl, 0, nil
+}
+
+func (h *FormHandler) GetTemplates(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	private, err := h.TemplateRepo.GetListingByUserID(r.Context(), usr.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	list := templates.List()
+
+	for _, model := range private {
+		tmpl, err := privateTemplate(model)
+
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+		}
+
+		list = append(list, tmpl)
+	}
+
+	return output.SuccessResponse(w, r, &GetTemplatesResponse{
+		Templates: list,
+	})
+}
+
+type SaveTemplateReqBody struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (h *FormHandler) SaveAsTemplate(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	var body SaveTemplateReqBody
+
+	if err := DecodeBody(r, &body); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	// default to the form's own name and description
+	if !validate.StrNotEmpty(body.Name) {
+		body.Name = form.Name
+		body.Description = derefString(form.Description)
+	}
+
+	var formData form_repo.FormData
+
+	if err := form.UnmarshalFormData(&formData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	model, err := h.TemplateRepo.Create(r.Context(), usr.ID, body.Name, optionalString(body.Description), formData)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to save template")
+	}
+
+	tmpl, err := privateTemplate(model)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &SaveTemplateResponse{
+		Template: tmpl,
+	})
+}
+
+func (h *FormHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	templateUuid, err := GetUUIDFromParams(r)
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	model, err := h.TemplateRepo.GetByUUID(r.Context(), *templateUuid)
+
+	if err != nil || model.UserID != usr.ID {
+		return http.StatusNotFound, fmt.Errorf("Template not found")
+	}
+
+	if err := h.TemplateRepo.Delete(r.Context(), model.ID); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to delete template")
+	}
+
+	return output.SuccessResponse(w, r, &output.MessageResponse{
+		Message: "Template deleted",
+	})
+}
@@ -6,7 +6,14 @@ import (
 	"formaura/pkg/constants"
 	user_repo "formaura/pkg/repositories/user"
 	"formaura/pkg/validate"
+	"log"
+	"net"
 	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -37,6 +44,203 @@ func GetUUIDFromParams(r *http.Request) (*string, error) {
 
 }
 
+func GetIntFromParams(r *http.Request, key string) (int, error) {
+	vars := mux.Vars(r)
+	n, err := strconv.Atoi(vars[key])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s is invalid", key)
+	}
+
+	return n, nil
+}
+
+func GetIntFromQuery(r *http.Request, key string) (int, error) {
+	n, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s is invalid", key)
+	}
+
+	return n, nil
+}
+
+// GetDateRangeFromQuery reads the optional from and to query params and
+// returns them as the half open range [from, before). A bare date in to moves
+// before to the start of the next day, any other to moves it on by a
+// microsecond, the precision timestamps are stored with.
+func GetDateRangeFromQuery(r *http.Request) (from *time.Time, before *time.Time, err error) {
+	query := r.URL.Query()
+
+	if s := query.Get("from"); s != "" {
+		t, ok := validate.ParseDate(s)
+		if !ok {
+			return nil, nil, fmt.Errorf("from is invalid")
+		}
+		from = &t
+	}
+
+	if s := query.Get("to"); s != "" {
+		t, ok := validate.ParseDate(s)
+		if !ok {
+			return nil, nil, fmt.Errorf("to is invalid")
+		}
+		if len(s) == len(validate.DateLayouts[0]) {
+			t = t.AddDate(0, 0, 1)
+		} else {
+			t = t.Add(time.Microsecond)
+		}
+		before = &t
+	}
+
+	if from != nil && before != nil && !from.Before(*before) {
+		return nil, nil, fmt.Errorf("from must be before to")
+	}
+
+	return from, before, nil
+}
+
+// trustedProxies are the proxies allowed to set X-Forwarded-For, a comma
+// separated list of addresses or CIDR ranges set with TRUSTED_PROXIES
+var trustedProxies = mustParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+
+func mustParseTrustedProxies(s string) []netip.Prefix {
+	proxies, err := ParseTrustedProxies(s)
+	if err != nil {
+		log.Fatalf("TRUSTED_PROXIES is invalid: %v", err)
+	}
+	return proxies
+}
+
+// ParseTrustedProxies reads a comma separated list of addresses and CIDR ranges
+func ParseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return proxies, nil
+}
+
+// GetClientIP returns the caller's address, see ClientIP
+func GetClientIP(r *http.Request) string {
+	return ClientIP(r, trustedProxies)
+}
+
+// ClientIP returns the address the request came from. X-Forwarded-For is only
+// read when that address is a trusted proxy, then the rightmost hop that is
+// not a trusted proxy is the client, hops further left can be set by anyone.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+
+	if !isTrustedProxy(remote, trusted) {
+		return remote
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			// not written by a proxy we trust, stop at the last good hop
+			break
+		}
+
+		client = addr.Unmap().String()
+		if !isTrustedProxy(client, trusted) {
+			break
+		}
+	}
+
+	return client
+}
+
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestURL returns the absolute url the request was made to, without the
+// query, built from its scheme and host
+func RequestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// GetIfMatchRevision reads the draft revision the client last saw from the
+// If-Match header, accepting both strong and weak ETags. Call it after the
+// ownership check so a non owner gets a 404 rather than learning the form exists.
+func GetIfMatchRevision(r *http.Request) (int, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		return 0, fmt.Errorf("If-Match header is required")
+	}
+
+	ifMatch = strings.TrimPrefix(ifMatch, "W/")
+	revision, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+	if err != nil || revision < 1 {
+		return 0, fmt.Errorf("If-Match header is invalid")
+	}
+
+	return revision, nil
+}
+
 func DecodeBody(r *http.Request, dst any) error {
 	return json.NewDecoder(r.Body).Decode(dst)
 }
+
+// optionalString maps an empty string to nil so it is stored as NULL
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
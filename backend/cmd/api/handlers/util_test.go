@@ -0,0 +1,70 @@
+package handlers_test
+
+import (
+	"formaura/cmd/api/handlers"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := handlers.ParseTrustedProxies("10.0.0.0/8, 192.168.1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer spoofing", "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"spoofed leftmost hop", "10.0.0.2:80", []string{"1.2.3.4, 198.51.100.9"}, "198.51.100.9"},
+		{"chain of proxies", "10.0.0.2:80", []string{"198.51.100.9, 192.168.1.5, 10.1.1.1"}, "198.51.100.9"},
+		{"repeated headers", "10.0.0.2:80", []string{"1.2.3.4", "198.51.100.9"}, "198.51.100.9"},
+		{"only proxies", "10.0.0.2:80", []string{"10.0.0.3"}, "10.0.0.3"},
+		{"garbage hop", "10.0.0.2:80", []string{"198.51.100.9, nonsense"}, "10.0.0.2"},
+		{"no header", "10.0.0.2:80", nil, "10.0.0.2"},
+		{"ipv6", "[2001:db8::1]:443", []string{"1.2.3.4"}, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+
+			if got := handlers.ClientIP(r, trusted); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := handlers.ParseTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("invalid prefix accepted")
+	}
+	if _, err := handlers.ParseTrustedProxies("proxy.local"); err == nil {
+		t.Error("hostname accepted")
+	}
+	if proxies, err := handlers.ParseTrustedProxies(""); err != nil || len(proxies) != 0 {
+		t.Errorf("empty list = %v, %v", proxies, err)
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://api.example.com/api/form/abc/schema.json?x=1", nil)
+	if got := handlers.RequestURL(r); got != "http://api.example.com/api/form/abc/schema.json" {
+		t.Errorf("RequestURL() = %q", got)
+	}
+
+	r = httptest.NewRequest("GET", "https://api.example.com/api/form/abc/schema.json", nil)
+	if got := handlers.RequestURL(r); got != "https://api.example.com/api/form/abc/schema.json" {
+		t.Errorf("RequestURL() over tls = %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"formaura/pkg/jsonschema"
+	"formaura/pkg/output"
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/validate"
+	"net/http"
+)
+
+type GetVersionsResponse struct {
+	Versions []*form_repo.VersionModel `json:"versions"`
+}
+
+type PublishFormResponse struct {
+	Version *form_repo.VersionModel `json:"version"`
+	Issues  validate.Issues         `json:"issues"`
+}
+
+func (h *FormHandler) PublishForm(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	var formData form_repo.FormData
+
+	if err := form.UnmarshalFormData(&formData); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	if len(formData.Steps) == 0 {
+		return http.StatusBadRequest, fmt.Errorf("Add at least one step before publishing")
+	}
+
+	// the draft may predate the linter so check it again before it goes live
+	issues := validate.LintFormData(&formData)
+
+	if issues.HasErrors() {
+		return output.ErrorResponse(w, r, http.StatusUnprocessableEntity, &FormDataErrorResponse{
+			Message: "Form data invalid",
+			Issues:  issues,
+		})
+	}
+
+	version, err := h.FormRepo.Publish(r.Context(), form.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Unable to publish form")
+	}
+
+	return output.SuccessResponse(w, r, &PublishFormResponse{
+		Version: version,
+		Issues:  issues,
+	})
+}
+
+func (h *FormHandler) GetVersions(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	versions, err := h.FormRepo.GetVersionsByFormID(r.Context(), form.ID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	return output.SuccessResponse(w, r, &GetVersionsResponse{
+		Versions: versions,
+	})
+}
+
+// GetSubmissionSchema serves a JSON Schema for submissions to the published
+// version, drafts are not described since they cannot be submitted to
+func (h *FormHandler) GetSubmissionSchema(w http.ResponseWriter, r *http.Request) (int, error) {
+	usr, err := GetUserFromCtx(r)
+
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	form, status, err := h.getUserForm(r, usr)
+
+	if err != nil {
+		return status, err
+	}
+
+	if form.PublishedVersionID == nil {
+		return http.StatusNotFound, fmt.Errorf("Form has not been published")
+	}
+
+	version, err := h.FormRepo.GetVersionByID(r.Context(), *form.PublishedVersionID)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	schema, err := jsonschema.FromVersion(version)
+
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Internal server error")
+	}
+
+	// the route the schema is served from, under /api
+	schema.ID = RequestURL(r)
+
+	return output.SuccessResponse(w, r, schema)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"formaura/cmd/api/handlers"
+	"formaura/pkg/middleware"
+	"formaura/pkg/output"
+
+	"github.com/gorilla/mux"
+)
+
+func ExportRoutes(r *mux.Router, h *handlers.ExportHandler, authCached middleware.Middleware) {
+	output.MakeRoute(r, "/{uuid}", h.GetExport, authCached).Methods("GET", "OPTIONS")
+	// public, the signed link is the authorization
+	output.MakeRoute(r, "/{uuid}/download", h.DownloadExport).Methods("GET", "OPTIONS")
+}
@@ -11,9 +11,34 @@ import (
 func FormRoutes(r *mux.Router, h *handlers.FormHandler, authCached middleware.Middleware) {
 	output.MakeRoute(r, "/list", h.GetDetailedListing, authCached).Methods("GET", "OPTIONS")
 	output.MakeRoute(r, "/new", h.NewForm, authCached).Methods("POST", "OPTIONS")
+	output.MakeRoute(r, "/import", h.ImportForm, authCached).Methods("POST", "OPTIONS")
 	output.MakeRoute(r, "/view/{uuid}", h.GetForm, authCached).Methods("GET", "OPTIONS")
 	output.MakeRoute(r, "/update/{uuid}/data", h.UpdateFormData, authCached).Methods("PUT", "OPTIONS")
+	output.MakeRoute(r, "/update/{uuid}/data", h.PatchFormData, authCached).Methods("PATCH", "OPTIONS")
 	output.MakeRoute(r, "/update/{uuid}/meta", h.UpdateFormMeta, authCached).Methods("PUT", "OPTIONS")
+	output.MakeRoute(r, "/update/{uuid}/search-language", h.UpdateSearchLanguage, authCached).Methods("PUT", "OPTIONS")
 	output.MakeRoute(r, "/update/{uuid}/affiliates", h.UpdateFormAffiliates, authCached).Methods("PUT", "OPTIONS")
 	output.MakeRoute(r, "/delete/{uuid}", h.DeleteForm, authCached).Methods("DELETE", "OPTIONS")
+	output.MakeRoute(r, "/trash", h.GetTrash, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/trash/{uuid}/restore", h.RestoreForm, authCached).Methods("POST", "OPTIONS")
+	output.MakeRoute(r, "/trash/{uuid}", h.PurgeForm, authCached).Methods("DELETE", "OPTIONS")
+	output.MakeRoute(r, "/templates", h.GetTemplates, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/templates/{uuid}", h.DeleteTemplate, authCached).Methods("DELETE", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/save-as-template", h.SaveAsTemplate, authCached).Methods("POST", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/export", h.ExportForm, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/duplicate", h.DuplicateForm, authCached).Methods("POST", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/publish", h.PublishForm, authCached).Methods("POST", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/analytics", h.GetAnalytics, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/submissions", h.GetSubmissions, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/submissions/export", h.ExportSubmissions, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/exports", h.CreateExport, authCached).Methods("POST", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/submissions/{submission_uuid}", h.GetSubmission, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/funnel", h.GetFunnel, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/reports/fields", h.GetFieldsReport, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/schema.json", h.GetSubmissionSchema, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/versions", h.GetVersions, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/revisions", h.GetRevisions, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/revisions/diff", h.DiffRevisions, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/revisions/{revision:[0-9]+}", h.GetRevision, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/revisions/{revision:[0-9]+}/restore", h.RestoreRevision, authCached).Methods("POST", "OPTIONS")
 }
@@ -15,10 +15,12 @@ func Register(
 	authHandlers *handlers.AuthHandler,
 	formHandlers *handlers.FormHandler,
 	submissionHandlers *handlers.SubmissionHandler,
+	exportHandlers *handlers.ExportHandler,
 
 	//middlewares
 	authFresh middleware.Middleware,
-	authCached middleware.Middleware) {
+	authCached middleware.Middleware,
+	authOptional middleware.Middleware) {
 
 	output.MakeSubRouter(r, "/auth", func(sr *mux.Router) {
 		AuthRoutes(sr, authHandlers, authCached)
@@ -27,7 +29,10 @@ func Register(
 		FormRoutes(sr, formHandlers, authCached)
 	})
 	output.MakeSubRouter(r, "/submission", func(sr *mux.Router) {
-		SubmissionRoutes(sr, submissionHandlers)
+		SubmissionRoutes(sr, submissionHandlers, authCached, authOptional)
+	})
+	output.MakeSubRouter(r, "/exports", func(sr *mux.Router) {
+		ExportRoutes(sr, exportHandlers, authCached)
 	})
 
 }
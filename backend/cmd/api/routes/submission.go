@@ -2,12 +2,16 @@ package routes
 
 import (
 	"formaura/cmd/api/handlers"
+	"formaura/pkg/middleware"
 	"formaura/pkg/output"
 
 	"github.com/gorilla/mux"
 )
 
-func SubmissionRoutes(r *mux.Router, h *handlers.SubmissionHandler) {
-	output.MakeRoute(r, "/{uuid}", h.GetForm).Methods("GET", "OPTIONS")
-	output.MakeRoute(r, "/{uuid}/submit", h.GetForm).Methods("POST", "OPTIONS")
+func SubmissionRoutes(r *mux.Router, h *handlers.SubmissionHandler, authCached middleware.Middleware, authOptional middleware.Middleware) {
+	output.MakeRoute(r, "/{uuid}", h.GetForm, authOptional).Methods("GET", "OPTIONS")
+	// /{uuid} is the public form, a stored submission is read by its owner here
+	output.MakeRoute(r, "/record/{uuid}", h.GetSubmission, authCached).Methods("GET", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/submit", h.SubmitForm).Methods("POST", "OPTIONS")
+	output.MakeRoute(r, "/{uuid}/events", h.TrackEvent, authOptional).Methods("POST", "OPTIONS")
 }
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"formaura/pkg/db"
+	form_repo "formaura/pkg/repositories/form"
+	"log"
+)
+
+// runReconcileCounters recomputes the denormalized submission counters, run
+// it after restoring a backup or editing form_submissions by hand
+func runReconcileCounters(ctx context.Context, args []string) error {
+	pool, err := db.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close(pool)
+
+	fixed, err := form_repo.NewFormRepo(pool).ReconcileCounters(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🧮 Reconciled counters, %d row(s) corrected", fixed)
+
+	return nil
+}
@@ -0,0 +1,49 @@
+// formctl runs maintenance tasks against the database outside of the API,
+// eg. bulk moving forms between accounts or environments
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "export", usage: "export -email <user email> -out <dir>", run: runExport},
+	{name: "import", usage: "import -email <user email> <file or dir>...", run: runImport},
+	{name: "reconcile-counters", usage: "reconcile-counters", run: runReconcileCounters},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: formctl <command> [flags]")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  formctl %s\n", c.usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(context.Background(), os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", c.name, err)
+			}
+			return
+		}
+	}
+
+	usage()
+}
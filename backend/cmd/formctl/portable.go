@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"formaura/pkg/db"
+	"formaura/pkg/portable"
+	form_repo "formaura/pkg/repositories/form"
+	user_repo "formaura/pkg/repositories/user"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runExport writes every form a user owns to <out>/<form uuid>.formaura.json
+func runExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	email := flags.String("email", "", "owner of the forms to export")
+	out := flags.String("out", ".", "directory to write documents to")
+	flags.Parse(args)
+
+	if *email == "" {
+		return fmt.Errorf("-email is required")
+	}
+
+	pool, err := db.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close(pool)
+
+	usr, err := user_repo.NewUserRepo(pool).GetByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+
+	formRepo := form_repo.NewFormRepo(pool)
+
+	listing, err := formRepo.GetBasicListingByUserID(ctx, usr.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	for _, item := range listing {
+		form, err := formRepo.GetByUUID(ctx, item.UUID)
+		if err != nil {
+			return err
+		}
+
+		doc, err := portable.Export(form)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(*out, form.UUID+".formaura.json")
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			return err
+		}
+
+		log.Printf("📦 Exported %q to %s", form.Name, path)
+	}
+
+	log.Printf("📦 Exported %d form(s)", len(listing))
+
+	return nil
+}
+
+// runImport creates a draft for every document given, directories are read
+// for *.json files
+func runImport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	email := flags.String("email", "", "user to import the forms into")
+	flags.Parse(args)
+
+	if *email == "" || flags.NArg() == 0 {
+		return fmt.Errorf("-email and at least one file or directory are required")
+	}
+
+	paths, err := importPaths(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	pool, err := db.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close(pool)
+
+	usr, err := user_repo.NewUserRepo(pool).GetByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+
+	formRepo := form_repo.NewFormRepo(pool)
+
+	listing, err := formRepo.GetBasicListingByUserID(ctx, usr.ID)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, path := range paths {
+		form, err := importFile(ctx, formRepo, usr.ID, path, listing)
+		if err != nil {
+			// keep going so one bad document does not stop a bulk migration
+			log.Printf("❌ %s: %v", path, err)
+			failed++
+			continue
+		}
+
+		listing = append(listing, form)
+		log.Printf("📥 Imported %s as %q (%s)", path, form.Name, form.UUID)
+	}
+
+	log.Printf("📥 Imported %d form(s), %d failed", len(paths)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d document(s) could not be imported", failed)
+	}
+
+	return nil
+}
+
+func importFile(ctx context.Context, formRepo *form_repo.FormRepository, userId int, path string, listing []*form_repo.FormModel) (*form_repo.FormModel, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := portable.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	formData, _, err := portable.Prepare(doc)
+	if err != nil {
+		var invalid *portable.InvalidFormDataError
+		if errors.As(err, &invalid) {
+			for _, issue := range invalid.Issues {
+				log.Printf("   %s %s: %s", issue.Severity, issue.Path, issue.Message)
+			}
+		}
+		return nil, err
+	}
+
+	name := form_repo.GenerateFormNumberedName(doc.Form.Name, listing)
+
+	return formRepo.Create(ctx, userId, name, doc.Form.Description, *formData)
+}
+
+func importPaths(args []string) ([]string, error) {
+	paths := []string{}
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+				paths = append(paths, filepath.Join(arg, entry.Name()))
+			}
+		}
+	}
+
+	return paths, nil
+}
@@ -6,9 +6,11 @@ toolchain go1.23.4
 
 require (
 	github.com/georgysavva/scany v1.2.3
+	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
 	github.com/jackc/pgx/v4 v4.18.3
 	github.com/joho/godotenv v1.5.1
+	github.com/sendgrid/sendgrid-go v3.16.1+incompatible
 )
 
 require (
@@ -22,7 +24,6 @@ require (
 	github.com/jackc/puddle v1.3.0 // indirect
 	github.com/mfridman/interpolate v0.0.2 // indirect
 	github.com/sendgrid/rest v2.6.9+incompatible // indirect
-	github.com/sendgrid/sendgrid-go v3.16.1+incompatible // indirect
 	github.com/sethvargo/go-retry v0.3.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
@@ -33,5 +34,4 @@ require (
 	github.com/pressly/goose/v3 v3.24.0
 	golang.org/x/crypto v0.31.0
 	golang.org/x/text v0.21.0 // indirect
-
 )
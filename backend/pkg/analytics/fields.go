@@ -0,0 +1,265 @@
+package analytics
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	"formaura/pkg/validate"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// HistogramBuckets is the most buckets a number field is split into
+const HistogramBuckets = 10
+
+var reportableFieldTypes = []string{
+	validate.FieldSelect,
+	validate.FieldRadio,
+	validate.FieldCheckbox,
+	validate.FieldNumber,
+	validate.FieldDate,
+}
+
+type OptionCount struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int    `json:"count"`
+	// share of the field's responses that chose the option, checkbox rates
+	// can add up to more than 1
+	Rate float64 `json:"rate"`
+}
+
+type Bucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+type NumberSummary struct {
+	Min       float64   `json:"min"`
+	Mean      float64   `json:"mean"`
+	Max       float64   `json:"max"`
+	Histogram []*Bucket `json:"histogram"`
+}
+
+type DateCount struct {
+	Period time.Time `json:"period"`
+	Count  int       `json:"count"`
+}
+
+type DateSummary struct {
+	Earliest     time.Time    `json:"earliest"`
+	Latest       time.Time    `json:"latest"`
+	Granularity  string       `json:"granularity"`
+	Distribution []*DateCount `json:"distribution"`
+}
+
+type FieldReport struct {
+	UUID      string         `json:"uuid"`
+	StepUUID  string         `json:"step_uuid"`
+	Label     string         `json:"label"`
+	Type      string         `json:"type"`
+	Responses int            `json:"responses"`
+	Options   []*OptionCount `json:"options,omitempty"`
+	Number    *NumberSummary `json:"number,omitempty"`
+	Date      *DateSummary   `json:"date,omitempty"`
+}
+
+type FieldsReport struct {
+	Submissions int            `json:"submissions"`
+	Fields      []*FieldReport `json:"fields"`
+}
+
+// ReportableFields returns the uuids of the fields BuildFieldsReport reports on
+func ReportableFields(formData *form_repo.FormData) []string {
+	uuids := []string{}
+	for _, step := range formData.Steps {
+		for _, field := range step.Fields {
+			if slices.Contains(reportableFieldTypes, field.Type) {
+				uuids = append(uuids, field.UUID)
+			}
+		}
+	}
+	return uuids
+}
+
+// BuildFieldsReport summarises the answer counts of every reportable field in
+// form order. Dates are bucketed by granularity.
+func BuildFieldsReport(formData *form_repo.FormData, submissions int, counts []*submission_repo.AnswerCount, granularity string) *FieldsReport {
+	responses := map[string]int{}
+	values := map[string]map[string]int{}
+
+	for _, c := range counts {
+		if c.Total {
+			responses[c.FieldUUID] = c.Count
+			continue
+		}
+		if c.Value == nil {
+			continue
+		}
+		if values[c.FieldUUID] == nil {
+			values[c.FieldUUID] = map[string]int{}
+		}
+		values[c.FieldUUID][*c.Value] += c.Count
+	}
+
+	report := &FieldsReport{Submissions: submissions, Fields: []*FieldReport{}}
+
+	for _, step := range formData.Steps {
+		for _, field := range step.Fields {
+			if !slices.Contains(reportableFieldTypes, field.Type) {
+				continue
+			}
+
+			fr := &FieldReport{
+				UUID:      field.UUID,
+				StepUUID:  step.UUID,
+				Label:     field.Label,
+				Type:      field.Type,
+				Responses: responses[field.UUID],
+			}
+
+			switch field.Type {
+			case validate.FieldNumber:
+				fr.Number = summariseNumbers(values[field.UUID])
+			case validate.FieldDate:
+				fr.Date = summariseDates(values[field.UUID], granularity)
+			default:
+				fr.Options = countOptions(&field, values[field.UUID], fr.Responses)
+			}
+
+			report.Fields = append(report.Fields, fr)
+		}
+	}
+
+	return report
+}
+
+// countOptions lists the field's options in order, including unchosen ones.
+// Values that are not a current option, eg. an option removed since an earlier
+// version, follow using the value as their label.
+func countOptions(field *form_repo.Field, values map[string]int, responses int) []*OptionCount {
+	options := []*OptionCount{}
+	seen := map[string]bool{}
+
+	for _, o := range field.Options {
+		if seen[o.Value] {
+			continue
+		}
+		seen[o.Value] = true
+		options = append(options, &OptionCount{
+			Value: o.Value,
+			Label: o.Label,
+			Count: values[o.Value],
+			Rate:  rate(values[o.Value], responses),
+		})
+	}
+
+	other := []string{}
+	for value := range values {
+		if !seen[value] {
+			other = append(other, value)
+		}
+	}
+	sort.Strings(other)
+
+	for _, value := range other {
+		options = append(options, &OptionCount{
+			Value: value,
+			Label: value,
+			Count: values[value],
+			Rate:  rate(values[value], responses),
+		})
+	}
+
+	return options
+}
+
+// summariseNumbers splits the range of answers into equal width buckets, the
+// last bucket includes the max. Answers that are not numbers are ignored.
+func summariseNumbers(values map[string]int) *NumberSummary {
+	numbers := map[float64]int{}
+	total, sum := 0, 0.0
+
+	for value, count := range values {
+		n, ok := validate.ToNumber(value)
+		if !ok {
+			continue
+		}
+		numbers[n] += count
+		total += count
+		sum += n * float64(count)
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	summary := &NumberSummary{Min: math.Inf(1), Max: math.Inf(-1)}
+	for n := range numbers {
+		summary.Min = math.Min(summary.Min, n)
+		summary.Max = math.Max(summary.Max, n)
+	}
+	summary.Mean = math.Round(sum/float64(total)*10000) / 10000
+
+	buckets := min(HistogramBuckets, len(numbers))
+	width := (summary.Max - summary.Min) / float64(buckets)
+
+	for i := 0; i < buckets; i++ {
+		summary.Histogram = append(summary.Histogram, &Bucket{
+			From: summary.Min + width*float64(i),
+			To:   summary.Min + width*float64(i+1),
+		})
+	}
+	summary.Histogram[buckets-1].To = summary.Max
+
+	for n, count := range numbers {
+		i := buckets - 1
+		if width > 0 {
+			i = min(int((n-summary.Min)/width), buckets-1)
+		}
+		summary.Histogram[i].Count += count
+	}
+
+	return summary
+}
+
+// summariseDates counts answers per period, only periods with answers are
+// listed. Answers that are not dates are ignored.
+func summariseDates(values map[string]int, granularity string) *DateSummary {
+	periods := map[time.Time]int{}
+	var summary *DateSummary
+
+	for value, count := range values {
+		d, ok := validate.ParseDate(value)
+		if !ok {
+			continue
+		}
+
+		if summary == nil {
+			summary = &DateSummary{Earliest: d, Latest: d, Granularity: granularity}
+		}
+		if d.Before(summary.Earliest) {
+			summary.Earliest = d
+		}
+		if d.After(summary.Latest) {
+			summary.Latest = d
+		}
+
+		periods[PeriodStart(d, granularity)] += count
+	}
+
+	if summary == nil {
+		return nil
+	}
+
+	for period, count := range periods {
+		summary.Distribution = append(summary.Distribution, &DateCount{Period: period, Count: count})
+	}
+	sort.Slice(summary.Distribution, func(i, j int) bool {
+		return summary.Distribution[i].Period.Before(summary.Distribution[j].Period)
+	})
+
+	return summary
+}
@@ -0,0 +1,70 @@
+package analytics_test
+
+import (
+	"formaura/pkg/analytics"
+	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	"testing"
+)
+
+func answer(field, value string, count int) *submission_repo.AnswerCount {
+	return &submission_repo.AnswerCount{FieldUUID: field, Value: &value, Count: count}
+}
+
+func total(field string, count int) *submission_repo.AnswerCount {
+	return &submission_repo.AnswerCount{FieldUUID: field, Total: true, Count: count}
+}
+
+func TestBuildFieldsReport(t *testing.T) {
+	formData := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Fields: []form_repo.Field{
+				{UUID: "name", Type: "text"},
+				{UUID: "size", Type: "radio", Options: []form_repo.Option{{Value: "s", Label: "Small"}, {Value: "l", Label: "Large"}}},
+				{UUID: "extras", Type: "checkbox", Options: []form_repo.Option{{Value: "a", Label: "A"}, {Value: "b", Label: "B"}}},
+			}},
+			{UUID: "s2", Fields: []form_repo.Field{
+				{UUID: "budget", Type: "number"},
+				{UUID: "start", Type: "date"},
+			}},
+		},
+	}
+
+	counts := []*submission_repo.AnswerCount{
+		total("size", 4), answer("size", "l", 3), answer("size", "m", 1),
+		total("extras", 2), answer("extras", "a", 2), answer("extras", "b", 1),
+		total("budget", 4), answer("budget", "0", 1), answer("budget", "10", 2), answer("budget", "100", 1),
+		total("start", 3), answer("start", "2026-01-05", 2), answer("start", "2026-03-20", 1),
+	}
+
+	report := analytics.BuildFieldsReport(formData, 5, counts, analytics.GranularityMonth)
+
+	if len(report.Fields) != 4 || report.Fields[0].UUID != "size" || report.Fields[3].UUID != "start" {
+		t.Fatalf("expected reportable fields in form order, got %+v", report.Fields)
+	}
+
+	size := report.Fields[0].Options
+	if len(size) != 3 || size[0].Count != 0 || size[1].Count != 3 || size[1].Rate != 0.75 {
+		t.Errorf("expected option counts in option order, got %+v", size)
+	}
+	if size[2].Value != "m" || size[2].Label != "m" {
+		t.Errorf("expected an unknown value to follow the options, got %+v", size[2])
+	}
+
+	if extras := report.Fields[1].Options; extras[0].Rate != 1 || extras[1].Rate != 0.5 {
+		t.Errorf("expected checkbox rates per response, got %+v", extras)
+	}
+
+	budget := report.Fields[2].Number
+	if budget.Min != 0 || budget.Max != 100 || budget.Mean != 30 {
+		t.Errorf("unexpected number summary %+v", budget)
+	}
+	if len(budget.Histogram) != 3 || budget.Histogram[0].Count != 3 || budget.Histogram[2].Count != 1 {
+		t.Errorf("unexpected histogram %+v", budget.Histogram)
+	}
+
+	start := report.Fields[3].Date
+	if len(start.Distribution) != 2 || start.Distribution[0].Count != 2 || start.Distribution[1].Period.Month() != 3 {
+		t.Errorf("unexpected date distribution %+v", start.Distribution)
+	}
+}
@@ -0,0 +1,69 @@
+package analytics
+
+import (
+	"math"
+
+	event_repo "formaura/pkg/repositories/event"
+	form_repo "formaura/pkg/repositories/form"
+)
+
+type FunnelStep struct {
+	UUID      string `json:"uuid"`
+	Title     string `json:"title"`
+	Viewed    int    `json:"viewed"`
+	Completed int    `json:"completed"`
+	// share of started sessions that reached the step
+	ReachRate float64 `json:"reach_rate"`
+	// share of sessions that viewed the step and completed it
+	CompletionRate float64 `json:"completion_rate"`
+	// share of sessions that viewed the step and never completed it
+	DropOffRate   float64  `json:"drop_off_rate"`
+	MedianSeconds *float64 `json:"median_seconds"`
+}
+
+type Funnel struct {
+	Started int           `json:"started"`
+	Steps   []*FunnelStep `json:"steps"`
+}
+
+func rate(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(of)*10000) / 10000
+}
+
+// BuildFunnel lays step stats out in the order of the form's steps. Steps in
+// the stats that are no longer in the form, eg. removed since an earlier
+// version, are left out.
+func BuildFunnel(formData *form_repo.FormData, started int, stats []*event_repo.StepStats) *Funnel {
+	byStep := map[string]*event_repo.StepStats{}
+	for _, s := range stats {
+		byStep[s.StepUUID] = s
+	}
+
+	funnel := &Funnel{
+		Started: started,
+		Steps:   []*FunnelStep{},
+	}
+
+	for _, step := range formData.Steps {
+		fs := &FunnelStep{UUID: step.UUID, Title: step.Title}
+
+		if s, ok := byStep[step.UUID]; ok {
+			fs.Viewed = s.Viewed
+			fs.Completed = s.Completed
+			fs.MedianSeconds = s.MedianSeconds
+		}
+
+		fs.ReachRate = rate(fs.Viewed, started)
+		fs.CompletionRate = rate(fs.Completed, fs.Viewed)
+		if fs.Viewed > 0 {
+			fs.DropOffRate = math.Round((1-float64(fs.Completed)/float64(fs.Viewed))*10000) / 10000
+		}
+
+		funnel.Steps = append(funnel.Steps, fs)
+	}
+
+	return funnel
+}
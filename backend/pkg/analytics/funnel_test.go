@@ -0,0 +1,46 @@
+package analytics_test
+
+import (
+	"formaura/pkg/analytics"
+	event_repo "formaura/pkg/repositories/event"
+	form_repo "formaura/pkg/repositories/form"
+	"testing"
+)
+
+func TestBuildFunnel(t *testing.T) {
+	formData := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Title: "About you"},
+			{UUID: "s2", Title: "Project"},
+			{UUID: "s3", Title: "Budget"},
+		},
+	}
+
+	median := 42.5
+	stats := []*event_repo.StepStats{
+		{StepUUID: "s2", Viewed: 40, Completed: 10},
+		{StepUUID: "s1", Viewed: 100, Completed: 80, MedianSeconds: &median},
+		{StepUUID: "removed", Viewed: 5, Completed: 5},
+	}
+
+	funnel := analytics.BuildFunnel(formData, 100, stats)
+
+	if len(funnel.Steps) != 3 || funnel.Steps[0].UUID != "s1" || funnel.Steps[1].UUID != "s2" {
+		t.Fatalf("expected steps in form order, got %+v", funnel.Steps)
+	}
+
+	first := funnel.Steps[0]
+	if first.CompletionRate != 0.8 || first.DropOffRate != 0.2 || first.ReachRate != 1 || *first.MedianSeconds != 42.5 {
+		t.Errorf("unexpected first step %+v", first)
+	}
+
+	second := funnel.Steps[1]
+	if second.ReachRate != 0.4 || second.CompletionRate != 0.25 || second.DropOffRate != 0.75 || second.MedianSeconds != nil {
+		t.Errorf("unexpected second step %+v", second)
+	}
+
+	// never viewed, every rate is zero rather than NaN
+	if third := funnel.Steps[2]; third.Viewed != 0 || third.CompletionRate != 0 || third.DropOffRate != 0 {
+		t.Errorf("unexpected third step %+v", third)
+	}
+}
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"fmt"
+	form_repo "formaura/pkg/repositories/form"
+	"math"
+	"time"
+)
+
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+var ValidGranularities = []string{GranularityDay, GranularityWeek, GranularityMonth}
+
+// Point is the activity for one period, Period is the first day of it
+type Point struct {
+	Period               time.Time      `json:"period"`
+	Views                int            `json:"views"`
+	UniqueViews          int            `json:"unique_views"`
+	Starts               int            `json:"starts"`
+	Submissions          int            `json:"submissions"`
+	AffiliateSubmissions map[string]int `json:"affiliate_submissions"`
+	ConversionRate       float64        `json:"conversion_rate"`
+}
+
+type Report struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Granularity string    `json:"granularity"`
+	Totals      *Point    `json:"totals"`
+	Series      []*Point  `json:"series"`
+}
+
+// PeriodStart returns the first day of the period day falls in, weeks start
+// on Monday
+func PeriodStart(day time.Time, granularity string) time.Time {
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	switch granularity {
+	case GranularityWeek:
+		// time.Sunday is 0, shift so Monday is the first day
+		offset := (int(start.Weekday()) + 6) % 7
+		return start.AddDate(0, 0, -offset)
+	case GranularityMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return start
+}
+
+func nextPeriod(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func newPoint(period time.Time) *Point {
+	return &Point{Period: period, AffiliateSubmissions: map[string]int{}}
+}
+
+func (p *Point) add(s *form_repo.DailyStatsModel) error {
+	p.Views += s.Views
+	p.UniqueViews += s.UniqueViews
+	p.Starts += s.Starts
+	p.Submissions += s.Submissions
+
+	affiliates, err := s.GetAffiliateSubmissions()
+	if err != nil {
+		return err
+	}
+	for uuid, n := range affiliates {
+		p.AffiliateSubmissions[uuid] += n
+	}
+
+	return nil
+}
+
+// conversionRate is submissions per unique visitor, rounded to 4 places.
+// Submissions made without a counted view can push it over 1.
+func (p *Point) conversionRate() {
+	if p.UniqueViews == 0 {
+		p.ConversionRate = 0
+		return
+	}
+	p.ConversionRate = math.Round(float64(p.Submissions)/float64(p.UniqueViews)*10000) / 10000
+}
+
+// Rollup buckets daily stats for the days [from, to] into periods, every
+// period in the range is present even when it had no activity
+func Rollup(stats []*form_repo.DailyStatsModel, from, to time.Time, granularity string) (*Report, error) {
+	report := &Report{
+		From:        PeriodStart(from, GranularityDay),
+		To:          PeriodStart(to, GranularityDay),
+		Granularity: granularity,
+		Totals:      newPoint(PeriodStart(from, GranularityDay)),
+		Series:      []*Point{},
+	}
+
+	if report.To.Before(report.From) {
+		return nil, fmt.Errorf("analytics: to is before from")
+	}
+
+	index := map[time.Time]*Point{}
+	for period := PeriodStart(report.From, granularity); !period.After(report.To); period = nextPeriod(period, granularity) {
+		point := newPoint(period)
+		index[period] = point
+		report.Series = append(report.Series, point)
+	}
+
+	for _, s := range stats {
+		point, ok := index[PeriodStart(s.Day, granularity)]
+		if !ok {
+			continue
+		}
+		if err := point.add(s); err != nil {
+			return nil, fmt.Errorf("analytics.Rollup: %w", err)
+		}
+		if err := report.Totals.add(s); err != nil {
+			return nil, fmt.Errorf("analytics.Rollup: %w", err)
+		}
+	}
+
+	for _, point := range report.Series {
+		point.conversionRate()
+	}
+	report.Totals.conversionRate()
+
+	return report, nil
+}
@@ -0,0 +1,62 @@
+package analytics_test
+
+import (
+	"formaura/pkg/analytics"
+	form_repo "formaura/pkg/repositories/form"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestPeriodStart(t *testing.T) {
+	cases := []struct {
+		day, granularity, want string
+	}{
+		{"2026-10-18", analytics.GranularityDay, "2026-10-18"},
+		// 18th is a Sunday, weeks start on the Monday before
+		{"2026-10-18", analytics.GranularityWeek, "2026-10-12"},
+		{"2026-10-19", analytics.GranularityWeek, "2026-10-19"},
+		{"2026-10-18", analytics.GranularityMonth, "2026-10-01"},
+	}
+
+	for _, c := range cases {
+		if got := analytics.PeriodStart(day(c.day), c.granularity); !got.Equal(day(c.want)) {
+			t.Errorf("%s %s: expected %s, got %s", c.day, c.granularity, c.want, got.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestRollup(t *testing.T) {
+	stats := []*form_repo.DailyStatsModel{
+		{Day: day("2026-10-12"), Views: 10, UniqueViews: 8, Submissions: 2, AffiliateSubmissions: []byte(`{"a": 1}`)},
+		{Day: day("2026-10-14"), Views: 5, UniqueViews: 2, Submissions: 1, AffiliateSubmissions: []byte(`{"a": 1}`)},
+		{Day: day("2026-10-26"), Views: 4, UniqueViews: 4, Submissions: 0, AffiliateSubmissions: []byte(`{}`)},
+	}
+
+	report, err := analytics.Rollup(stats, day("2026-10-12"), day("2026-10-31"), analytics.GranularityWeek)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// weeks of the 12th, 19th and 26th, the empty middle week is still present
+	if len(report.Series) != 3 {
+		t.Fatalf("expected 3 periods, got %d", len(report.Series))
+	}
+
+	first := report.Series[0]
+	if first.Views != 15 || first.Submissions != 3 || first.AffiliateSubmissions["a"] != 2 || first.ConversionRate != 0.3 {
+		t.Errorf("unexpected first week %+v", first)
+	}
+
+	if report.Series[1].Views != 0 || report.Series[1].ConversionRate != 0 {
+		t.Errorf("expected an empty second week, got %+v", report.Series[1])
+	}
+
+	if report.Totals.Views != 19 || report.Totals.UniqueViews != 14 || report.Totals.Submissions != 3 {
+		t.Errorf("unexpected totals %+v", report.Totals)
+	}
+}
@@ -2,6 +2,7 @@ package email
 
 import (
 	"fmt"
+	"html"
 	"strings"
 )
 
@@ -15,13 +16,23 @@ type ActionEmailTemplateData struct {
 	PrimaryActionURL    string
 	SecondaryActionText string
 	SecondaryActionURL  string
+	// EscapeContent marks Content, ListItems and BottomContent as plain text to
+	// be escaped in the HTML part, leave it off to write markup into them
+	EscapeContent bool
 }
 
 func GenerateEmailTemplate(data ActionEmailTemplateData) string {
 	var sb strings.Builder
 
+	text := func(s string) string {
+		if data.EscapeContent {
+			return html.EscapeString(s)
+		}
+		return s
+	}
+
 	for _, p := range data.Content {
-		sb.WriteString(fmt.Sprintf(`<p style="margin: 0 0 16px 0; color: #444; line-height: 1.6; font-size: 14px;">%s</p>`, p))
+		sb.WriteString(fmt.Sprintf(`<p style="margin: 0 0 16px 0; color: #444; line-height: 1.6; font-size: 14px;">%s</p>`, text(p)))
 	}
 	paragraphs := sb.String()
 	sb.Reset()
@@ -29,7 +40,7 @@ func GenerateEmailTemplate(data ActionEmailTemplateData) string {
 	if len(data.ListItems) > 0 {
 		sb.WriteString("<ul style='margin: 16px 0; padding-left: 20px;'>")
 		for _, item := range data.ListItems {
-			sb.WriteString(fmt.Sprintf(`<li style="margin-bottom: 8px; color: #444; line-height: 1.5; font-size: 14px;">%s</li>`, item))
+			sb.WriteString(fmt.Sprintf(`<li style="margin-bottom: 8px; color: #444; line-height: 1.5; font-size: 14px;">%s</li>`, text(item)))
 		}
 		sb.WriteString("</ul>")
 	}
@@ -37,7 +48,7 @@ func GenerateEmailTemplate(data ActionEmailTemplateData) string {
 	sb.Reset()
 
 	for _, p := range data.BottomContent {
-		sb.WriteString(fmt.Sprintf(`<p style="margin: 0 0 12px 0; color: #666; font-size: 13px; line-height: 1.5;">%s</p>`, p))
+		sb.WriteString(fmt.Sprintf(`<p style="margin: 0 0 12px 0; color: #666; font-size: 13px; line-height: 1.5;">%s</p>`, text(p)))
 	}
 	bottomParagraphs := sb.String()
 	sb.Reset()
@@ -0,0 +1,29 @@
+package email_test
+
+import (
+	"formaura/pkg/email"
+	"strings"
+	"testing"
+)
+
+func TestEscapeContent(t *testing.T) {
+	data := email.ActionEmailTemplateData{
+		Title:         "Your Export Is Ready",
+		Content:       []string{"Your export from Q&A <b>form</b> is ready."},
+		EscapeContent: true,
+	}
+
+	if html := email.GenerateEmailTemplate(data); !strings.Contains(html, "Q&amp;A &lt;b&gt;form&lt;/b&gt;") {
+		t.Errorf("expected escaped content in the html part")
+	}
+
+	if text := email.GeneratePlainTextEmail(data); !strings.Contains(text, "Q&A <b>form</b>") {
+		t.Errorf("expected raw content in the text part, got %q", text)
+	}
+
+	// markup is kept when content is not escaped, eg. the OTP code box
+	data.EscapeContent = false
+	if html := email.GenerateEmailTemplate(data); !strings.Contains(html, "Q&A <b>form</b>") {
+		t.Errorf("expected markup in the html part")
+	}
+}
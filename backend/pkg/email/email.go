@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
@@ -142,3 +144,59 @@ func (c *Client) SendOTP(data OTPEmailData) error {
 
 	return c.Send(options)
 }
+
+type ExportEmailData struct {
+	ToEmail     string
+	ToName      string
+	FormName    string
+	Submissions int
+	// DownloadURL is left out of the email when it is empty or not absolute
+	DownloadURL string
+	ExpiresAt   time.Time
+}
+
+// SendExportReady tells a user the submissions export they started has finished
+func (c *Client) SendExportReady(data ExportEmailData) error {
+	content := []string{
+		fmt.Sprintf("Your export of %d submission(s) from %s is ready to download.", data.Submissions, data.FormName),
+	}
+
+	templateData := ActionEmailTemplateData{
+		ReceiverName:  data.ToName,
+		Title:         "Your Export Is Ready",
+		Content:       content,
+		BottomContent: []string{fmt.Sprintf("The file is available until %s.", data.ExpiresAt.Format("January 2, 2006 15:04"))},
+		EscapeContent: true,
+	}
+
+	if strings.HasPrefix(data.DownloadURL, "http") {
+		templateData.PrimaryActionText = "Download Export"
+		templateData.PrimaryActionURL = data.DownloadURL
+	}
+
+	return c.Send(SendOptions{
+		ToEmail:      data.ToEmail,
+		ToName:       data.ToName,
+		Subject:      "Your formaura export is ready",
+		TemplateData: templateData,
+	})
+}
+
+// SendExportFailed tells a user the submissions export they started could not
+// be finished
+func (c *Client) SendExportFailed(data ExportEmailData) error {
+	return c.Send(SendOptions{
+		ToEmail: data.ToEmail,
+		ToName:  data.ToName,
+		Subject: "Your formaura export failed",
+		TemplateData: ActionEmailTemplateData{
+			ReceiverName: data.ToName,
+			Title:        "Your Export Failed",
+			Content: []string{
+				fmt.Sprintf("We were unable to export the submissions from %s.", data.FormName),
+				"Please try starting the export again.",
+			},
+			EscapeContent: true,
+		},
+	})
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	submission_repo "formaura/pkg/repositories/submission"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV writes a table as comma separated values a row at a time
+type CSV struct {
+	w     *csv.Writer
+	table *Table
+}
+
+func NewCSV(w io.Writer) *CSV {
+	return &CSV{w: csv.NewWriter(w)}
+}
+
+func (c *CSV) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (c *CSV) Extension() string {
+	return "csv"
+}
+
+func (c *CSV) Begin(t *Table) error {
+	c.table = t
+
+	header := t.Header()
+	cells := make([]string, len(header))
+	for i, h := range header {
+		cells[i] = escapeFormula(h)
+	}
+	return c.w.Write(cells)
+}
+
+func (c *CSV) Write(s *submission_repo.Model) error {
+	row, err := c.table.Row(s)
+	if err != nil {
+		return err
+	}
+
+	cells := make([]string, len(row))
+	for i, cell := range row {
+		cells[i] = formatCell(cell)
+	}
+	return c.w.Write(cells)
+}
+
+// Close flushes any buffered rows, it does not close the underlying writer
+func (c *CSV) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatCell(cell any) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case Date:
+		return formatDate(v)
+	case time.Time:
+		return formatTime(v)
+	}
+	return escapeFormula(fmt.Sprint(cell))
+}
+
+// escapeFormula stops spreadsheet apps evaluating answers as formulas, text
+// that starts with a formula character is prefixed with a quote
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"errors"
+	submission_repo "formaura/pkg/repositories/submission"
+	"io"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+var ValidFormats = []string{FormatCSV, FormatXLSX, FormatNDJSON}
+
+var ErrUnknownFormat = errors.New("export: unknown format")
+
+// Exporter writes submissions to a file format. Begin is called once before
+// any submission is written and Close always once at the end, even after an
+// error, so temporary files are released.
+type Exporter interface {
+	ContentType() string
+	Extension() string
+	Begin(t *Table) error
+	Write(s *submission_repo.Model) error
+	Close() error
+}
+
+type Options struct {
+	// SheetPerStep splits an XLSX into a sheet for each step, other formats
+	// ignore it
+	SheetPerStep bool
+}
+
+func New(format string, w io.Writer, opts Options) (Exporter, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSV(w), nil
+	case FormatXLSX:
+		return NewXLSX(w, opts.SheetPerStep), nil
+	case FormatNDJSON:
+		return NewNDJSON(w), nil
+	}
+	return nil, ErrUnknownFormat
+}
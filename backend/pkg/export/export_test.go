@@ -0,0 +1,144 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"formaura/pkg/export"
+	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTable() (*export.Table, *submission_repo.Model) {
+	current := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Title: "About you", Fields: []form_repo.Field{
+				{UUID: "company", Type: "text", Label: "Company"},
+				{UUID: "services", Type: "checkbox", Label: "Services", Options: []form_repo.Option{{Value: "web", Label: "Web design"}, {Value: "seo", Label: "SEO"}}},
+			}},
+			{UUID: "s2", Title: "Project", Fields: []form_repo.Field{
+				{UUID: "budget", Type: "number", Label: "Budget"},
+				{UUID: "start", Type: "date", Label: "Start date"},
+			}},
+		},
+	}
+	older := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Title: "About you", Fields: []form_repo.Field{
+				{UUID: "company", Type: "text", Label: "Company name"},
+				{UUID: "phone", Type: "text", Label: "Phone"},
+			}},
+		},
+	}
+
+	table := export.NewTable(current, []*form_repo.FormData{older}, []form_repo.AffiliateInfo{{UUID: "a1", FirstName: "Sam", LastName: "Lee"}})
+
+	affiliate := "a1"
+	data, _ := json.Marshal(map[string]any{
+		"company":  "=Acme",
+		"services": []string{"seo", "web", "print"},
+		"budget":   "2500",
+		"start":    "2026-11-02",
+		"phone":    "555 0100",
+	})
+
+	return table, &submission_repo.Model{
+		UUID:           "sub",
+		AffiliateUUID:  &affiliate,
+		SubmissionData: data,
+		SubmittedAt:    time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestTableRow(t *testing.T) {
+	table, submission := testTable()
+
+	header := table.Header()
+	fields := header[len(export.MetaHeaders):]
+	if strings.Join(fields, ",") != "Company,Services,Budget,Start date,Phone (removed)" {
+		t.Errorf("unexpected field headers %v", fields)
+	}
+
+	row, err := table.Row(submission)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if row[2] != "Sam Lee" || row[3] != nil {
+		t.Errorf("expected affiliate name and empty full name, got %v", row[:5])
+	}
+
+	cells := row[len(export.MetaHeaders):]
+	if cells[1] != "SEO; Web design; print" || cells[2] != 2500.0 || cells[4] != "555 0100" {
+		t.Errorf("unexpected field cells %v", cells)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	table, submission := testTable()
+
+	var buf bytes.Buffer
+	csv := export.NewCSV(&buf)
+
+	if err := csv.Begin(table); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := csv.Write(submission); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := csv.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[1] != "sub,2026-10-18 09:30:00,Sam Lee,,,'=Acme,SEO; Web design; print,2500,2026-11-02,555 0100" {
+		t.Errorf("unexpected csv %q", buf.String())
+	}
+}
+
+func TestXLSXSheetPerStep(t *testing.T) {
+	table, submission := testTable()
+
+	var buf bytes.Buffer
+	xlsx := export.NewXLSX(&buf, true)
+
+	if err := xlsx.Begin(table); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := xlsx.Write(submission); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := xlsx.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("expected a zip, got %v", err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	if !strings.Contains(files["xl/workbook.xml"], `name="About you"`) || !strings.Contains(files["xl/workbook.xml"], `name="Project"`) {
+		t.Errorf("expected a sheet per step, got %s", files["xl/workbook.xml"])
+	}
+
+	project := files["xl/worksheets/sheet2.xml"]
+	if !strings.Contains(project, `<c r="F2" s="0"><v>2500</v></c>`) {
+		t.Errorf("expected a typed number cell, got %s", project)
+	}
+	// 2026-11-02 is day 46328 of the 1900 date system
+	if !strings.Contains(project, `<c r="G2" s="2"><v>46328</v></c>`) {
+		t.Errorf("expected a typed date cell, got %s", project)
+	}
+}
@@ -0,0 +1,41 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	submission_repo "formaura/pkg/repositories/submission"
+	"io"
+)
+
+// NDJSON writes one submission per line as it is stored, answers are keyed
+// by field uuid as described by the form's schema.json
+type NDJSON struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func NewNDJSON(w io.Writer) *NDJSON {
+	buf := bufio.NewWriter(w)
+	return &NDJSON{w: buf, enc: json.NewEncoder(buf)}
+}
+
+func (n *NDJSON) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (n *NDJSON) Extension() string {
+	return "ndjson"
+}
+
+func (n *NDJSON) Begin(t *Table) error {
+	return nil
+}
+
+// Write encodes the submission followed by a newline
+func (n *NDJSON) Write(s *submission_repo.Model) error {
+	return n.enc.Encode(s)
+}
+
+func (n *NDJSON) Close() error {
+	return n.w.Flush()
+}
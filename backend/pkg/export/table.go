@@ -0,0 +1,247 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	"formaura/pkg/validate"
+	"strings"
+	"time"
+)
+
+// MetaHeaders head the columns every row starts with, before the fields
+var MetaHeaders = []string{"Submission ID", "Submitted at", "Affiliate", "Full name", "Email"}
+
+// checkboxSeparator joins the labels of the ticked options into one cell
+const checkboxSeparator = "; "
+
+// Column is one field of the form, Removed fields only exist in older
+// versions but may still have answers
+type Column struct {
+	StepUUID  string
+	StepTitle string
+	Field     form_repo.Field
+	Removed   bool
+	labels    map[string]string
+}
+
+func (c *Column) Header() string {
+	header := c.Field.Label
+	if header == "" {
+		header = c.Field.Name
+	}
+	if header == "" {
+		header = c.Field.UUID
+	}
+	if c.Removed {
+		header += " (removed)"
+	}
+	return header
+}
+
+// Date is the answer to a date field that has no time of day
+type Date time.Time
+
+// Table lays submissions out as rows of cells. Cells are nil when unanswered,
+// otherwise a string, a float64 for numbers, a Date or a time.Time.
+type Table struct {
+	Columns    []*Column
+	affiliates map[string]string
+}
+
+// NewTable makes a column for every field of current in step and field order,
+// followed by the fields that only appear in history, eg. the published
+// versions newest first, so answers to removed fields are not lost
+func NewTable(current *form_repo.FormData, history []*form_repo.FormData, affiliates []form_repo.AffiliateInfo) *Table {
+	t := &Table{affiliates: map[string]string{}}
+	seen := map[string]bool{}
+
+	add := func(formData *form_repo.FormData, removed bool) {
+		for _, step := range formData.Steps {
+			for _, field := range step.Fields {
+				if seen[field.UUID] {
+					continue
+				}
+				seen[field.UUID] = true
+
+				labels := map[string]string{}
+				for _, o := range field.Options {
+					labels[o.Value] = o.Label
+				}
+
+				t.Columns = append(t.Columns, &Column{
+					StepUUID:  step.UUID,
+					StepTitle: step.Title,
+					Field:     field,
+					Removed:   removed,
+					labels:    labels,
+				})
+			}
+		}
+	}
+
+	add(current, false)
+	for _, formData := range history {
+		add(formData, true)
+	}
+
+	for _, a := range affiliates {
+		t.affiliates[a.UUID] = strings.TrimSpace(a.FirstName + " " + a.LastName)
+	}
+
+	return t
+}
+
+// LoadTable lays the export out by the form's published fields, or its draft's
+// if it was never published, then any fields removed since an earlier version
+func LoadTable(ctx context.Context, repo form_repo.Repository, form *form_repo.FormModel) (*Table, error) {
+	var current form_repo.FormData
+
+	if form.PublishedVersionID != nil {
+		version, err := repo.GetVersionByID(ctx, *form.PublishedVersionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := version.UnmarshalFormData(&current); err != nil {
+			return nil, err
+		}
+	} else if err := form.UnmarshalFormData(&current); err != nil {
+		return nil, err
+	}
+
+	versions, err := repo.GetVersionSnapshotsByFormID(ctx, form.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*form_repo.FormData, 0, len(versions))
+	for _, v := range versions {
+		var versionData form_repo.FormData
+		if err := v.UnmarshalFormData(&versionData); err != nil {
+			return nil, err
+		}
+		history = append(history, &versionData)
+	}
+
+	affiliates, err := form.GetAffiliates()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTable(&current, history, affiliates), nil
+}
+
+func (t *Table) Header() []string {
+	header := append([]string{}, MetaHeaders...)
+	for _, c := range t.Columns {
+		header = append(header, c.Header())
+	}
+	return header
+}
+
+func (t *Table) Row(s *submission_repo.Model) ([]any, error) {
+	var answers map[string]any
+	if err := s.UnmarshalSubmissionData(&answers); err != nil {
+		return nil, fmt.Errorf("export: submission %s: %w", s.UUID, err)
+	}
+
+	row := []any{s.UUID, s.SubmittedAt, t.affiliate(s.AffiliateUUID), optional(s.FullName), optional(s.Email)}
+
+	for _, c := range t.Columns {
+		row = append(row, c.cell(answers[c.Field.UUID]))
+	}
+
+	return row, nil
+}
+
+// affiliate names an affiliate, falling back to its uuid when it is no longer
+// attached to the form
+func (t *Table) affiliate(uuid *string) any {
+	if uuid == nil {
+		return nil
+	}
+	if name := t.affiliates[*uuid]; name != "" {
+		return name
+	}
+	return *uuid
+}
+
+func optional(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// cell converts an answer for the column's field, option values become their
+// labels and unknown values are kept as they are
+func (c *Column) cell(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+
+	case bool:
+		// a checkbox without options
+		if v {
+			return "Yes"
+		}
+		return "No"
+
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+		labels := make([]string, 0, len(v))
+		for _, item := range v {
+			labels = append(labels, c.label(fmt.Sprint(item)))
+		}
+		return strings.Join(labels, checkboxSeparator)
+
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		switch c.Field.Type {
+		case validate.FieldNumber:
+			if n, ok := validate.ToNumber(v); ok {
+				return n
+			}
+		case validate.FieldDate:
+			if d, ok := validate.ParseDate(v); ok {
+				if len(v) == len(validate.DateLayouts[0]) {
+					return Date(d)
+				}
+				return d
+			}
+		}
+		return c.label(v)
+
+	case float64:
+		return v
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+func (c *Column) label(value string) string {
+	if label, ok := c.labels[value]; ok && label != "" {
+		return label
+	}
+	return value
+}
+
+// formatTime is how times are written as text, submitted_at is stored in
+// server local time without a zone
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func formatDate(d Date) string {
+	return time.Time(d).Format("2006-01-02")
+}
@@ -0,0 +1,366 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	submission_repo "formaura/pkg/repositories/submission"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cell styles, indexes into cellXfs in xlsxStyles
+const (
+	styleDefault  = 0
+	styleHeader   = 1
+	styleDate     = 2
+	styleDateTime = 3
+)
+
+// maxSheetName is the longest sheet name Excel opens
+const maxSheetName = 31
+
+// excelEpoch is day zero of the 1900 date system, shifted past Excel's
+// phantom 29th of February 1900
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+var sheetNameReplacer = strings.NewReplacer(
+	"[", "(", "]", ")", ":", "-", "*", "-", "?", "", "/", "-", `\`, "-",
+)
+
+// XLSX writes an Excel workbook with typed number and date cells. Zip entries
+// have to be written one after another, so each sheet's rows are spooled to a
+// temporary file and the workbook is assembled on Close.
+type XLSX struct {
+	w            io.Writer
+	sheetPerStep bool
+	table        *Table
+	sheets       []*xlsxSheet
+	closed       bool
+}
+
+type xlsxSheet struct {
+	name string
+	// indexes into a table row of the cells on this sheet
+	cells []int
+	file  *os.File
+	buf   *bufio.Writer
+	rows  int
+}
+
+func NewXLSX(w io.Writer, sheetPerStep bool) *XLSX {
+	return &XLSX{w: w, sheetPerStep: sheetPerStep}
+}
+
+func (x *XLSX) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (x *XLSX) Extension() string {
+	return "xlsx"
+}
+
+// Begin lays out the sheets, every sheet starts with the metadata columns so
+// rows can be matched up across step sheets by submission id
+func (x *XLSX) Begin(t *Table) error {
+	x.table = t
+
+	meta := make([]int, len(MetaHeaders))
+	for i := range meta {
+		meta[i] = i
+	}
+
+	if !x.sheetPerStep {
+		cells := meta
+		for i := range t.Columns {
+			cells = append(cells, len(MetaHeaders)+i)
+		}
+		x.sheets = []*xlsxSheet{{name: "Submissions", cells: cells}}
+	} else {
+		byStep := map[string]*xlsxSheet{}
+		for i, c := range t.Columns {
+			sheet, ok := byStep[c.StepUUID]
+			if !ok {
+				sheet = &xlsxSheet{name: c.StepTitle, cells: append([]int{}, meta...)}
+				byStep[c.StepUUID] = sheet
+				x.sheets = append(x.sheets, sheet)
+			}
+			sheet.cells = append(sheet.cells, len(MetaHeaders)+i)
+		}
+		if len(x.sheets) == 0 {
+			x.sheets = []*xlsxSheet{{name: "Submissions", cells: meta}}
+		}
+	}
+
+	names := map[string]bool{}
+	header := t.Header()
+
+	for i, sheet := range x.sheets {
+		sheet.name = uniqueSheetName(sheet.name, i+1, names)
+
+		file, err := os.CreateTemp("", "formaura-xlsx-*")
+		if err != nil {
+			return fmt.Errorf("export: xlsx spool: %w", err)
+		}
+		sheet.file = file
+		sheet.buf = bufio.NewWriter(file)
+
+		row := make([]any, len(sheet.cells))
+		for j, index := range sheet.cells {
+			row[j] = header[index]
+		}
+		if err := sheet.writeRow(row, styleHeader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *XLSX) Write(s *submission_repo.Model) error {
+	row, err := x.table.Row(s)
+	if err != nil {
+		return err
+	}
+
+	for _, sheet := range x.sheets {
+		cells := make([]any, len(sheet.cells))
+		for j, index := range sheet.cells {
+			cells[j] = row[index]
+		}
+		if err := sheet.writeRow(cells, styleDefault); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close writes the workbook and removes the spooled sheets, after a failed
+// Begin or Write it only cleans up
+func (x *XLSX) Close() error {
+	if x.closed {
+		return nil
+	}
+	x.closed = true
+
+	defer func() {
+		for _, sheet := range x.sheets {
+			if sheet.file != nil {
+				sheet.file.Close()
+				os.Remove(sheet.file.Name())
+			}
+		}
+	}()
+
+	if x.table == nil {
+		return nil
+	}
+
+	zw := zip.NewWriter(x.w)
+
+	if err := writeZipEntry(zw, "[Content_Types].xml", x.contentTypes()); err != nil {
+		return err
+	}
+	if err := writeZipEntry(zw, "_rels/.rels", xlsxRootRels); err != nil {
+		return err
+	}
+	if err := writeZipEntry(zw, "xl/workbook.xml", x.workbook()); err != nil {
+		return err
+	}
+	if err := writeZipEntry(zw, "xl/_rels/workbook.xml.rels", x.workbookRels()); err != nil {
+		return err
+	}
+	if err := writeZipEntry(zw, "xl/styles.xml", xlsxStyles); err != nil {
+		return err
+	}
+
+	for i, sheet := range x.sheets {
+		if err := sheet.copyTo(zw, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (s *xlsxSheet) writeRow(row []any, style int) error {
+	s.rows++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, s.rows)
+
+	for i, cell := range row {
+		ref := columnName(i) + strconv.Itoa(s.rows)
+
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case float64:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+		case Date:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDate, excelSerial(time.Time(v)))
+		case time.Time:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDateTime, excelSerial(v))
+		default:
+			fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			xml.EscapeText(&b, []byte(fmt.Sprint(v)))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+
+	b.WriteString(`</row>`)
+
+	if _, err := s.buf.WriteString(b.String()); err != nil {
+		return fmt.Errorf("export: xlsx spool: %w", err)
+	}
+	return nil
+}
+
+// copyTo wraps the spooled rows in a worksheet with the header row frozen
+func (s *xlsxSheet) copyTo(zw *zip.Writer, name string) error {
+	if err := s.buf.Flush(); err != nil {
+		return fmt.Errorf("export: xlsx spool: %w", err)
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("export: xlsx spool: %w", err)
+	}
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("export: xlsx %s: %w", name, err)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`+
+		`<sheetData>`); err != nil {
+		return fmt.Errorf("export: xlsx %s: %w", name, err)
+	}
+	if _, err := io.Copy(w, s.file); err != nil {
+		return fmt.Errorf("export: xlsx %s: %w", name, err)
+	}
+	if _, err := io.WriteString(w, `</sheetData></worksheet>`); err != nil {
+		return fmt.Errorf("export: xlsx %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func writeZipEntry(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("export: xlsx %s: %w", name, err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		return fmt.Errorf("export: xlsx %s: %w", name, err)
+	}
+	return nil
+}
+
+func (x *XLSX) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range x.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (x *XLSX) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range x.sheets {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(sheet.name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+// workbookRels links the sheets as rId1..n and the styles after them
+func (x *XLSX) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range x.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(x.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the cell styles, 14 and 22 are Excel's built in date and
+// date time formats so they follow the reader's locale
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+// columnName converts a zero based column index to its letters, 0 is A and 26 is AA
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+// excelSerial is the number of days since the epoch, the wall clock time is
+// kept as Excel has no time zones
+func excelSerial(t time.Time) string {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	days := wall.Sub(excelEpoch).Hours() / 24
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+// uniqueSheetName makes a valid sheet name, falling back to "Step n" and
+// numbering any that would clash
+func uniqueSheetName(name string, n int, taken map[string]bool) string {
+	name = strings.TrimSpace(sheetNameReplacer.Replace(name))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = fmt.Sprintf("Step %d", n)
+	}
+
+	base := truncateRunes(name, maxSheetName)
+	name = base
+	for i := 2; taken[strings.ToLower(name)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		name = truncateRunes(base, maxSheetName-len(suffix)) + suffix
+	}
+
+	taken[strings.ToLower(name)] = true
+	return name
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
@@ -0,0 +1,359 @@
+package exportjob
+
+import (
+	"context"
+	"errors"
+	"formaura/pkg/db"
+	"formaura/pkg/email"
+	"formaura/pkg/export"
+	export_repo "formaura/pkg/repositories/export"
+	form_repo "formaura/pkg/repositories/form"
+	submission_repo "formaura/pkg/repositories/submission"
+	user_repo "formaura/pkg/repositories/user"
+	"formaura/pkg/storage"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultRetentionDays = 7
+
+const (
+	pollInterval    = 5 * time.Second
+	cleanupInterval = time.Minute
+	// a running job that has not reported progress for this long is requeued
+	stallTimeout = 15 * time.Minute
+	// progress is saved every this many submissions
+	progressEvery = 500
+)
+
+// LinkTTL is how long a download link from the api stays valid, links in the
+// email last until the file expires
+const LinkTTL = time.Hour
+
+var errFormNotFound = errors.New("exportjob: form not found")
+
+// Retention is how long a finished export is kept for download, set with
+// EXPORT_RETENTION_DAYS
+func Retention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("EXPORT_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DownloadPath is the route a job's file is downloaded from
+func DownloadPath(jobUUID string) string {
+	return "/api/exports/" + jobUUID + "/download"
+}
+
+// DownloadURL signs a link to a finished job's file, valid for ttl but never
+// past the file's expiry
+func DownloadURL(signer *storage.Signer, job *export_repo.Model, now time.Time, ttl time.Duration) (string, time.Time) {
+	expires := now.Add(ttl)
+	if job.ExpiresAt != nil && job.ExpiresAt.Before(expires) {
+		expires = *job.ExpiresAt
+	}
+	return signer.URL(DownloadPath(job.UUID), expires), expires
+}
+
+// Worker runs queued submission exports one at a time, writing the files to
+// storage and emailing the user who started each job when it finishes. It
+// also requeues stalled jobs and deletes expired ones.
+type Worker struct {
+	jobs        export_repo.Repository
+	forms       form_repo.Repository
+	submissions submission_repo.Repository
+	users       user_repo.Repository
+	storage     storage.Storage
+	signer      *storage.Signer
+	emailClient *email.Client
+	retention   time.Duration
+	interval    time.Duration
+	// cancelled by Stop so a long export is interrupted and requeued
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewWorker(
+	jobs export_repo.Repository,
+	forms form_repo.Repository,
+	submissions submission_repo.Repository,
+	users user_repo.Repository,
+	store storage.Storage,
+	signer *storage.Signer,
+	emailClient *email.Client,
+	retention time.Duration) *Worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Worker{
+		jobs:        jobs,
+		forms:       forms,
+		submissions: submissions,
+		users:       users,
+		storage:     store,
+		signer:      signer,
+		emailClient: emailClient,
+		retention:   retention,
+		interval:    pollInterval,
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+func (w *Worker) Start() {
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		cleanup := time.NewTicker(cleanupInterval)
+		defer cleanup.Stop()
+
+		w.cleanup()
+		w.poll()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.poll()
+			case <-cleanup.C:
+				w.cleanup()
+			case <-w.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop interrupts a running export, which is queued again for the next
+// worker, and waits for the loop to end
+func (w *Worker) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+// poll runs queued jobs until the queue is empty
+func (w *Worker) poll() {
+	for w.ctx.Err() == nil {
+		job, err := w.jobs.ClaimNext(w.ctx)
+		if err != nil {
+			if w.ctx.Err() == nil {
+				log.Printf("Export job claim failed: %v", err)
+			}
+			return
+		}
+
+		if job == nil {
+			return
+		}
+
+		w.run(job)
+	}
+}
+
+func (w *Worker) run(job *export_repo.Model) {
+	form, processed, key, err := w.export(job)
+
+	// bookkeeping still has to happen when the worker is stopping
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err != nil && w.ctx.Err() != nil {
+		if err := w.jobs.Requeue(ctx, job.ID); err != nil {
+			log.Printf("Export job %s requeue failed: %v", job.UUID, err)
+		}
+		return
+	}
+
+	// expiry is stored as a UTC timestamp
+	expiresAt := time.Now().UTC().Add(w.retention)
+
+	if err != nil {
+		log.Printf("Export job %s failed: %v", job.UUID, err)
+
+		reason := "Unable to export submissions"
+		if errors.Is(err, errFormNotFound) {
+			reason = "Form not found"
+		}
+
+		if err := w.jobs.Fail(ctx, job.ID, reason, expiresAt); err != nil {
+			log.Printf("Export job %s fail failed: %v", job.UUID, err)
+		}
+
+		w.notify(ctx, job, form, processed, false)
+		return
+	}
+
+	if err := w.jobs.Complete(ctx, job.ID, processed, key, expiresAt); err != nil {
+		log.Printf("Export job %s complete failed: %v", job.UUID, err)
+		w.storage.Delete(ctx, key)
+		return
+	}
+
+	job.ExpiresAt = &expiresAt
+	log.Printf("📦 Exported %d submission(s) for job %s", processed, job.UUID)
+
+	w.notify(ctx, job, form, processed, true)
+}
+
+// export writes the job's file to storage and returns its key
+func (w *Worker) export(job *export_repo.Model) (*form_repo.FormModel, int, string, error) {
+	ctx := w.ctx
+
+	if job.FormID == nil {
+		return nil, 0, "", errFormNotFound
+	}
+
+	form, err := w.forms.GetByID(ctx, *job.FormID)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			err = errFormNotFound
+		}
+		return nil, 0, "", err
+	}
+
+	filter, err := job.GetFilter()
+	if err != nil {
+		return form, 0, "", err
+	}
+
+	// search in the language submissions are indexed in now, it may have
+	// changed since the job was queued
+	filter.SearchLanguage = form.SearchLanguage
+
+	total, err := w.submissions.CountByFormID(ctx, form.ID, *filter)
+	if err != nil {
+		return form, 0, "", err
+	}
+
+	if err := w.jobs.SetTotal(ctx, job.ID, total); err != nil {
+		return form, 0, "", err
+	}
+
+	table, err := export.LoadTable(ctx, w.forms, form)
+	if err != nil {
+		return form, 0, "", err
+	}
+
+	// spooled locally first so storage only ever receives complete files
+	tmp, err := os.CreateTemp("", "formaura-export-*")
+	if err != nil {
+		return form, 0, "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	exporter, err := export.New(job.Format, tmp, export.Options{SheetPerStep: job.SheetPerStep})
+	if err != nil {
+		return form, 0, "", err
+	}
+
+	processed := 0
+
+	err = exporter.Begin(table)
+
+	if err == nil {
+		err = w.submissions.StreamByFormID(ctx, form.ID, *filter, func(s *submission_repo.Model) error {
+			if err := exporter.Write(s); err != nil {
+				return err
+			}
+
+			processed++
+
+			if processed%progressEvery == 0 {
+				return w.jobs.UpdateProgress(ctx, job.ID, processed)
+			}
+			return nil
+		})
+	}
+
+	if closeErr := exporter.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return form, processed, "", err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return form, processed, "", err
+	}
+
+	key := job.UUID + "." + exporter.Extension()
+
+	if err := w.storage.Put(ctx, key, tmp); err != nil {
+		return form, processed, "", err
+	}
+
+	return form, processed, key, nil
+}
+
+// notify emails the user who started the job, failures are only logged as
+// the job itself has already finished
+func (w *Worker) notify(ctx context.Context, job *export_repo.Model, form *form_repo.FormModel, processed int, ok bool) {
+	if w.emailClient == nil || job.UserID == nil {
+		return
+	}
+
+	user, err := w.users.GetByID(ctx, *job.UserID)
+	if err != nil || user == nil {
+		log.Printf("Export job %s email skipped, user not found: %v", job.UUID, err)
+		return
+	}
+
+	data := email.ExportEmailData{
+		ToEmail:     user.Email,
+		ToName:      strings.TrimSpace(user.FirstName + " " + user.LastName),
+		FormName:    "your form",
+		Submissions: processed,
+	}
+
+	if form != nil {
+		data.FormName = form.Name
+	}
+
+	if ok {
+		data.DownloadURL, data.ExpiresAt = DownloadURL(w.signer, job, time.Now(), w.retention)
+		err = w.emailClient.SendExportReady(data)
+	} else {
+		err = w.emailClient.SendExportFailed(data)
+	}
+
+	if err != nil {
+		log.Printf("Export job %s email failed: %v", job.UUID, err)
+	}
+}
+
+// cleanup requeues jobs whose worker died and deletes expired jobs and files
+func (w *Worker) cleanup() {
+	ctx, cancel := context.WithTimeout(w.ctx, time.Minute)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	requeued, err := w.jobs.RequeueStalled(ctx, stallTimeout, now.Add(w.retention))
+	if err != nil {
+		log.Printf("Export job requeue failed: %v", err)
+	} else if requeued > 0 {
+		log.Printf("Requeued %d stalled export job(s)", requeued)
+	}
+
+	keys, err := w.jobs.DeleteExpired(ctx, now)
+	if err != nil {
+		log.Printf("Export cleanup failed: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		if err := w.storage.Delete(ctx, key); err != nil {
+			log.Printf("Export file %s delete failed: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// ErrTestFailed is returned when a "test" operation does not match, the
+// whole patch is rejected and nothing is applied
+var ErrTestFailed = errors.New("jsonpatch: test operation failed")
+
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is an RFC 6902 document. As an extension, a path segment that points
+// into an array may be the "uuid" of one of its objects instead of an index,
+// eg. /steps/{step uuid}/fields/{field uuid}/label
+type Patch []Operation
+
+// Apply runs every operation against doc in order and returns the patched
+// document, if any operation fails the original doc is left untouched
+func Apply(doc []byte, patch Patch) ([]byte, error) {
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("jsonpatch: invalid document: %w", err)
+	}
+
+	for i, op := range patch {
+		var err error
+		root, err = applyOperation(root, &op)
+		if err != nil {
+			if errors.Is(err, ErrTestFailed) {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			return nil, fmt.Errorf("jsonpatch: operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func applyOperation(root any, op *Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case OpAdd:
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+
+	case OpRemove:
+		_, root, err = remove(root, path)
+		return root, err
+
+	case OpReplace:
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := get(root, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		return mutate(root, path, func(parent any, key string) (any, error) {
+			return setChild(parent, key, value)
+		})
+
+	case OpMove:
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, fmt.Errorf("cannot move a value into one of its children")
+		}
+		value, root, err := remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+
+	case OpCopy:
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		value, err = deepCopy(value)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+
+	case OpTest:
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+func (op *Operation) value() (any, error) {
+	if op.Value == nil {
+		return nil, fmt.Errorf("value is required")
+	}
+	var v any
+	if err := json.Unmarshal(op.Value, &v); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	return v, nil
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex resolves a path token against an array, either a plain index or
+// the uuid of an object in the array
+func arrayIndex(arr []any, key string) (int, error) {
+	if idx, err := strconv.Atoi(key); err == nil && key == strconv.Itoa(idx) {
+		if idx < 0 || idx >= len(arr) {
+			return 0, fmt.Errorf("index %d out of range", idx)
+		}
+		return idx, nil
+	}
+
+	for i, item := range arr {
+		if obj, ok := item.(map[string]any); ok && obj["uuid"] == key {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%q not found", key)
+}
+
+func getChild(node any, key string) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("%q not found", key)
+		}
+		return child, nil
+	case []any:
+		idx, err := arrayIndex(n, key)
+		if err != nil {
+			return nil, err
+		}
+		return n[idx], nil
+	}
+	return nil, fmt.Errorf("cannot index into a scalar with %q", key)
+}
+
+func setChild(node any, key string, value any) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		n[key] = value
+		return n, nil
+	case []any:
+		idx, err := arrayIndex(n, key)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = value
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot index into a scalar with %q", key)
+}
+
+func get(root any, path []string) (any, error) {
+	node := root
+	for _, key := range path {
+		child, err := getChild(node, key)
+		if err != nil {
+			return nil, err
+		}
+		node = child
+	}
+	return node, nil
+}
+
+// mutate walks to the parent of the last path token and replaces it with
+// whatever leaf returns, slices are rebuilt on the way back up
+func mutate(node any, path []string, leaf func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return leaf(node, path[0])
+	}
+
+	child, err := getChild(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = mutate(child, path[1:], leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	return setChild(node, path[0], child)
+}
+
+func add(root any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return mutate(root, path, func(parent any, key string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			p[key] = value
+			return p, nil
+		case []any:
+			idx := len(p)
+			if key != "-" {
+				n, err := strconv.Atoi(key)
+				if err != nil {
+					// a uuid inserts before the matching element
+					if n, err = arrayIndex(p, key); err != nil {
+						return nil, err
+					}
+				}
+				if n < 0 || n > len(p) {
+					return nil, fmt.Errorf("index %d out of range", n)
+				}
+				idx = n
+			}
+			p = append(p, nil)
+			copy(p[idx+1:], p[idx:])
+			p[idx] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("cannot add %q to a scalar", key)
+	})
+}
+
+func remove(root any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	var removed any
+
+	root, err := mutate(root, path, func(parent any, key string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			child, ok := p[key]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", key)
+			}
+			removed = child
+			delete(p, key)
+			return p, nil
+		case []any:
+			idx, err := arrayIndex(p, key)
+			if err != nil {
+				return nil, err
+			}
+			removed = p[idx]
+			return append(p[:idx], p[idx+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove %q from a scalar", key)
+	})
+
+	return removed, root, err
+}
+
+func deepCopy(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
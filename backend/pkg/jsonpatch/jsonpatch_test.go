@@ -0,0 +1,84 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"formaura/pkg/jsonpatch"
+	"testing"
+)
+
+const doc = `{"steps":[{"uuid":"s1","fields":[{"uuid":"f1","label":"Name"},{"uuid":"f2","label":"Email"}]}]}`
+
+func applyJSON(t *testing.T, patch string) (string, error) {
+	t.Helper()
+
+	var p jsonpatch.Patch
+	if err := json.Unmarshal([]byte(patch), &p); err != nil {
+		t.Fatalf("invalid patch: %v", err)
+	}
+
+	out, err := jsonpatch.Apply([]byte(doc), p)
+	return string(out), err
+}
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{
+			"replace by index",
+			`[{"op":"replace","path":"/steps/0/fields/1/label","value":"Work email"}]`,
+			`{"steps":[{"fields":[{"label":"Name","uuid":"f1"},{"label":"Work email","uuid":"f2"}],"uuid":"s1"}]}`,
+		},
+		{
+			"replace by uuid",
+			`[{"op":"replace","path":"/steps/s1/fields/f1/label","value":"Full name"}]`,
+			`{"steps":[{"fields":[{"label":"Full name","uuid":"f1"},{"label":"Email","uuid":"f2"}],"uuid":"s1"}]}`,
+		},
+		{
+			"add to end and remove",
+			`[{"op":"add","path":"/steps/0/fields/-","value":{"uuid":"f3"}},{"op":"remove","path":"/steps/0/fields/f1"}]`,
+			`{"steps":[{"fields":[{"label":"Email","uuid":"f2"},{"uuid":"f3"}],"uuid":"s1"}]}`,
+		},
+		{
+			"move",
+			`[{"op":"move","from":"/steps/0/fields/1","path":"/steps/0/fields/0"}]`,
+			`{"steps":[{"fields":[{"label":"Email","uuid":"f2"},{"label":"Name","uuid":"f1"}],"uuid":"s1"}]}`,
+		},
+		{
+			"copy",
+			`[{"op":"copy","from":"/steps/0/fields/0/label","path":"/steps/0/title"}]`,
+			`{"steps":[{"fields":[{"label":"Name","uuid":"f1"},{"label":"Email","uuid":"f2"}],"title":"Name","uuid":"s1"}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		got, err := applyJSON(t, c.patch)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s:\n got  %s\n want %s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	_, err := applyJSON(t, `[{"op":"test","path":"/steps/0/fields/0/label","value":"Nope"}]`)
+	if !errors.Is(err, jsonpatch.ErrTestFailed) {
+		t.Errorf("expected ErrTestFailed, got %v", err)
+	}
+
+	_, err = applyJSON(t, `[{"op":"remove","path":"/steps/0/fields/9"}]`)
+	if err == nil {
+		t.Error("expected out of range error")
+	}
+
+	_, err = applyJSON(t, `[{"op":"move","from":"/steps/0","path":"/steps/0/fields/0"}]`)
+	if err == nil {
+		t.Error("expected error moving into a child")
+	}
+}
@@ -0,0 +1,235 @@
+package jsonschema
+
+import (
+	"fmt"
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/validate"
+	"math"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema draft 2020-12 needed to describe a
+// submission. Anything the server checks that JSON Schema cannot express,
+// eg. min/max dates, is noted in the description instead.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Version is the published form version the schema was generated from
+	Version int `json:"x-formaura-version,omitempty"`
+
+	// Type is a type name or, when more than one is allowed, a list of them
+	Type       any                `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	Enum   []string `json:"enum,omitempty"`
+	Const  any      `json:"const,omitempty"`
+	Format string   `json:"format,omitempty"`
+
+	Pattern   string `json:"pattern,omitempty"`
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+}
+
+// blank matches a string the validator treats as not filled in
+var blank = &Schema{Pattern: `^\s*$`}
+
+// numeric matches the strings validate.ToNumber accepts as numbers
+const numeric = `^\s*[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?\s*$`
+
+// FromVersion describes the body accepted by POST /submission/{uuid}/submit
+// for a published version. Answers are keyed by field UUID. Fields hidden by a
+// condition are never required by the schema since visibility depends on the
+// other answers, the server still enforces them when they are shown.
+func FromVersion(version *form_repo.VersionModel) (*Schema, error) {
+	var formData form_repo.FormData
+
+	if err := version.UnmarshalFormData(&formData); err != nil {
+		return nil, fmt.Errorf("jsonschema.FromVersion: %w", err)
+	}
+
+	data := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+		Required:   []string{},
+	}
+
+	for _, step := range formData.Steps {
+		for _, field := range step.Fields {
+			data.Properties[field.UUID] = fieldSchema(&field)
+
+			conditional := step.Condition != nil || field.Condition != nil
+			if field.Required && !conditional {
+				data.Required = append(data.Required, field.UUID)
+			}
+		}
+	}
+
+	return &Schema{
+		Schema:      Draft,
+		Title:       version.Name,
+		Description: derefString(version.Description),
+		Version:     version.Version,
+		Type:        "object",
+		Properties: map[string]*Schema{
+			"affiliate_uuid": {Type: "string", Format: "uuid"},
+			"full_name":      {Type: "string"},
+			"email": {Type: "string", AnyOf: []*Schema{
+				{MaxLength: intPtr(0)},
+				{Format: "email"},
+			}},
+			"submission_data": data,
+		},
+		Required: []string{"submission_data"},
+	}, nil
+}
+
+func fieldSchema(field *form_repo.Field) *Schema {
+	s := &Schema{Title: field.Label}
+	v := field.Validation
+	if v == nil {
+		v = &form_repo.Validation{}
+	}
+
+	switch field.Type {
+	case validate.FieldNumber:
+		// numbers typed into text inputs arrive as strings, the limits below
+		// only apply to json numbers so the server checks them for both
+		number := "number"
+		if isTrue(v.Integer) {
+			number = "integer"
+		}
+		s.Type = []string{number, "string"}
+		s.Pattern = numeric
+		s.Minimum = v.Min
+		s.Maximum = v.Max
+		s.ExclusiveMinimum = v.MoreThan
+		s.ExclusiveMaximum = v.LessThan
+		if isTrue(v.Positive) {
+			s.ExclusiveMinimum = maxPtr(s.ExclusiveMinimum, 0)
+		}
+		if isTrue(v.Negative) {
+			s.ExclusiveMaximum = minPtr(s.ExclusiveMaximum, 0)
+		}
+		if s.Minimum != nil || s.Maximum != nil || s.ExclusiveMinimum != nil || s.ExclusiveMaximum != nil || number == "integer" {
+			s.Description = "Numeric strings must meet the same limits."
+		}
+
+	case validate.FieldDate:
+		s.Type = "string"
+		s.AnyOf = []*Schema{{Format: "date"}, {Format: "date-time"}}
+		if v.MinDate != nil {
+			s.Description = appendSentence(s.Description, fmt.Sprintf("Must not be before %s.", *v.MinDate))
+		}
+		if v.MaxDate != nil {
+			s.Description = appendSentence(s.Description, fmt.Sprintf("Must not be after %s.", *v.MaxDate))
+		}
+
+	case validate.FieldCheckbox:
+		// a checkbox without options is a single tick box
+		if len(field.Options) == 0 {
+			s.Type = "boolean"
+			if field.Required {
+				s.Const = true
+			}
+			break
+		}
+		s.Type = "array"
+		s.Items = &Schema{Type: "string", Enum: optionValues(field)}
+		s.MinItems = v.MinItems
+		s.MaxItems = v.MaxItems
+		if field.Required && (s.MinItems == nil || *s.MinItems < 1) {
+			s.MinItems = intPtr(1)
+		}
+
+	case validate.FieldSelect, validate.FieldRadio:
+		s.Type = "string"
+		s.Enum = optionValues(field)
+
+	default:
+		s.Type = "string"
+		s.MinLength = v.MinLength
+		s.MaxLength = v.MaxLength
+		if v.Length != nil {
+			s.MinLength = v.Length
+			s.MaxLength = v.Length
+		}
+		if v.Matches != nil {
+			s.Pattern = *v.Matches
+		}
+		if field.Type == validate.FieldEmail || isTrue(v.Email) {
+			s.Format = "email"
+		}
+		if isTrue(v.URL) {
+			s.AllOf = append(s.AllOf, &Schema{Format: "uri"})
+		}
+		if isTrue(v.UUID) {
+			s.AllOf = append(s.AllOf, &Schema{Format: "uuid"})
+		}
+		if field.Required {
+			s.Not = blank
+		}
+	}
+
+	return s
+}
+
+func optionValues(field *form_repo.Field) []string {
+	values := make([]string, 0, len(field.Options))
+	for _, o := range field.Options {
+		values = append(values, o.Value)
+	}
+	return values
+}
+
+func maxPtr(p *float64, n float64) *float64 {
+	if p != nil {
+		n = math.Max(*p, n)
+	}
+	return &n
+}
+
+func minPtr(p *float64, n float64) *float64 {
+	if p != nil {
+		n = math.Min(*p, n)
+	}
+	return &n
+}
+
+func appendSentence(s, sentence string) string {
+	if s == "" {
+		return sentence
+	}
+	return s + " " + sentence
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
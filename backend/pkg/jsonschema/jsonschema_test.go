@@ -0,0 +1,95 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"formaura/pkg/jsonschema"
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/validate"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+func boolPtr(b bool) *bool    { return &b }
+
+func TestFromVersion(t *testing.T) {
+	formData := form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Fields: []form_repo.Field{
+				{UUID: "name", Type: "text", Label: "Name", Required: true, Validation: &form_repo.Validation{Matches: strPtr(`^[A-Z]`)}},
+				{UUID: "plan", Type: "select", Required: true, Options: []form_repo.Option{{Value: "basic"}, {Value: "pro"}}},
+				{UUID: "seats", Type: "number", Validation: &form_repo.Validation{Integer: boolPtr(true), Positive: boolPtr(true)}},
+				{UUID: "extras", Type: "checkbox", Required: true, Options: []form_repo.Option{{Value: "a"}}},
+				{UUID: "terms", Type: "checkbox", Required: true},
+				{UUID: "company", Type: "text", Required: true, Condition: &form_repo.Condition{Operator: "AND", Conditions: []form_repo.CondRule{
+					{Type: "field", UUID: "plan", Operator: "equals", Value: "pro"},
+				}}},
+			}},
+		},
+	}
+
+	b, err := json.Marshal(formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := jsonschema.FromVersion(&form_repo.VersionModel{Name: "Signup", Version: 3, FormData: b})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if schema.Schema != jsonschema.Draft || schema.Version != 3 {
+		t.Errorf("unexpected root %+v", schema)
+	}
+
+	data := schema.Properties["submission_data"]
+
+	// conditional fields are never unconditionally required
+	if want := []string{"name", "plan", "extras", "terms"}; !reflect.DeepEqual(data.Required, want) {
+		t.Errorf("expected required %v, got %v", want, data.Required)
+	}
+
+	if p := data.Properties["name"]; p.Pattern != `^[A-Z]` || p.Not == nil {
+		t.Errorf("expected pattern and non blank rule, got %+v", p)
+	}
+
+	if p := data.Properties["plan"]; !reflect.DeepEqual(p.Enum, []string{"basic", "pro"}) {
+		t.Errorf("expected enum of option values, got %+v", p.Enum)
+	}
+
+	if p := data.Properties["seats"]; !reflect.DeepEqual(p.Type, []string{"integer", "string"}) || p.ExclusiveMinimum == nil || *p.ExclusiveMinimum != 0 {
+		t.Errorf("expected positive integer or numeric string, got %+v", p)
+	}
+
+	if p := data.Properties["extras"]; p.Type != "array" || p.MinItems == nil || *p.MinItems != 1 {
+		t.Errorf("expected required array with minItems 1, got %+v", p)
+	}
+
+	if p := data.Properties["terms"]; p.Type != "boolean" || p.Const != true {
+		t.Errorf("expected required tick box to be const true, got %+v", p)
+	}
+}
+
+func TestNumberPatternMatchesServer(t *testing.T) {
+	b, err := json.Marshal(form_repo.FormData{Steps: []form_repo.Step{
+		{UUID: "s1", Fields: []form_repo.Field{{UUID: "n", Type: "number"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := jsonschema.FromVersion(&form_repo.VersionModel{FormData: b})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	pattern := regexp.MustCompile(schema.Properties["submission_data"].Properties["n"].Pattern)
+
+	for _, value := range []string{"42", "-3.5", "+7", ".5", "5.", " 12 ", "1e3", "2.5E-2", "", "abc", "1,000", "1.2.3", "e5", "NaN", "Inf", "--1"} {
+		_, accepted := validate.ToNumber(value)
+		if matched := pattern.MatchString(value); matched != accepted {
+			t.Errorf("%q: pattern matched %v, server accepts %v", value, matched, accepted)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package keyset
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("keyset: invalid cursor")
+
+// Cursor is the position after the last row of a page, listings sort on one
+// value and break ties on uuid. It records the sort it was made for so it
+// cannot be replayed against a different order.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Asc   bool   `json:"a"`
+	Value string `json:"v"`
+	UUID  string `json:"u"`
+}
+
+// Encode returns the cursor as an opaque url safe string
+func (c *Cursor) Encode() string {
+	// only strings and a bool, marshalling cannot fail
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode parses a cursor made for the given sort and direction
+func Decode(s string, sort string, asc bool) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.UUID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	if c.Sort != sort || c.Asc != asc {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+func FormatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func FormatInt(n int) string {
+	return strconv.Itoa(n)
+}
+
+func FormatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func (c *Cursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+func (c *Cursor) Int() (int64, error) {
+	n, err := strconv.ParseInt(c.Value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return n, nil
+}
+
+// Float rejects NaN and infinities, they would sort past every real value
+func (c *Cursor) Float() (float64, error) {
+	f, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrInvalidCursor
+	}
+	return f, nil
+}
+
+// Direction returns the ORDER BY direction and the comparison that keeps the
+// rows after a cursor, eg. (column, uuid) < ($1, $2) for a descending sort
+func Direction(asc bool) (dir string, cmp string) {
+	if asc {
+		return "ASC", ">"
+	}
+	return "DESC", "<"
+}
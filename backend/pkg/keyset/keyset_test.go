@@ -0,0 +1,100 @@
+package keyset_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"formaura/pkg/keyset"
+	"testing"
+	"time"
+)
+
+func encoded(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := &keyset.Cursor{Sort: "created", Asc: true, Value: "Q&A \"leads\"", UUID: "0192a0b4-0000-7000-8000-000000000001"}
+
+	decoded, err := keyset.Decode(c.Encode(), "created", true)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if *decoded != *c {
+		t.Errorf("expected %+v, got %+v", c, decoded)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	valid := `{"s":"created","a":false,"v":"x","u":"abc"}`
+
+	cases := map[string]struct {
+		cursor string
+		sort   string
+		asc    bool
+	}{
+		"not base64":      {"!!!", "created", false},
+		"not json":        {encoded("nope"), "created", false},
+		"no uuid":         {encoded(`{"s":"created","a":false,"v":"x"}`), "created", false},
+		"other sort":      {encoded(valid), "name", false},
+		"other direction": {encoded(valid), "created", true},
+		"truncated":       {encoded(valid)[1:], "created", false},
+	}
+
+	for name, c := range cases {
+		if _, err := keyset.Decode(c.cursor, c.sort, c.asc); !errors.Is(err, keyset.ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	at := time.Date(2026, 10, 18, 12, 30, 0, 123456789, time.UTC)
+
+	c := &keyset.Cursor{Value: keyset.FormatTime(at)}
+	if got, err := c.Time(); err != nil || !got.Equal(at) {
+		t.Errorf("time: got %v, %v", got, err)
+	}
+
+	c = &keyset.Cursor{Value: keyset.FormatInt(1234)}
+	if got, err := c.Int(); err != nil || got != 1234 {
+		t.Errorf("int: got %v, %v", got, err)
+	}
+
+	for _, f := range []float64{0, 0.0607927, 1e-20, 0.1 + 0.2} {
+		c = &keyset.Cursor{Value: keyset.FormatFloat(f)}
+		if got, err := c.Float(); err != nil || got != f {
+			t.Errorf("float %v: got %v, %v", f, got, err)
+		}
+	}
+
+	invalid := map[string]func(c *keyset.Cursor) error{
+		"time":  func(c *keyset.Cursor) error { _, err := c.Time(); return err },
+		"int":   func(c *keyset.Cursor) error { _, err := c.Int(); return err },
+		"float": func(c *keyset.Cursor) error { _, err := c.Float(); return err },
+	}
+
+	for name, parse := range invalid {
+		for _, value := range []string{"", "yesterday", "1.5.2"} {
+			if err := parse(&keyset.Cursor{Value: value}); !errors.Is(err, keyset.ErrInvalidCursor) {
+				t.Errorf("%s %q: expected ErrInvalidCursor, got %v", name, value, err)
+			}
+		}
+	}
+
+	for _, value := range []string{"NaN", "Inf", "-Inf"} {
+		if _, err := (&keyset.Cursor{Value: value}).Float(); !errors.Is(err, keyset.ErrInvalidCursor) {
+			t.Errorf("float %q: expected ErrInvalidCursor, got %v", value, err)
+		}
+	}
+}
+
+func TestDirection(t *testing.T) {
+	if dir, cmp := keyset.Direction(false); dir != "DESC" || cmp != "<" {
+		t.Errorf("descending: got %s %s", dir, cmp)
+	}
+
+	if dir, cmp := keyset.Direction(true); dir != "ASC" || cmp != ">" {
+		t.Errorf("ascending: got %s %s", dir, cmp)
+	}
+}
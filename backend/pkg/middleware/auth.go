@@ -87,3 +87,43 @@ func AuthAlwaysFreshMiddleware(repo user_repo.Repository, cache *user_memory_cac
 		})
 	}
 }
+
+// AuthOptionalMiddleware puts the user in the context when a valid auth token
+// is sent but never rejects the request, for public routes that behave
+// differently for a signed in user
+func AuthOptionalMiddleware(repo user_repo.Repository, cache *user_memory_cache.Cache) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(constants.AUTH_TOKEN_HEADER)
+			if len(token) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			parsed, err := jwt.Parse(token)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, ok := parsed["uuid"].(string)
+			if !ok || !validate.ValidateUUID(id) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			usr := cache.Get(id)
+			if usr == nil {
+				usr, err = repo.GetByUUID(r.Context(), id)
+				if err != nil || usr == nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+				cache.Set(id, usr)
+			}
+
+			ctx := context.WithValue(r.Context(), constants.USER_CTX, usr)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
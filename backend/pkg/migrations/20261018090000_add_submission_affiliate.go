@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubmissionAffiliate, downAddSubmissionAffiliate)
+}
+
+func upAddSubmissionAffiliate(ctx context.Context, tx *sql.Tx) error {
+	//---- link submissions to the affiliate that referred them
+	add_affiliate_column := `ALTER TABLE form_submissions
+		ADD COLUMN affiliate_id INTEGER REFERENCES affiliates(id) ON DELETE SET NULL`
+	_, err := tx.ExecContext(ctx, add_affiliate_column)
+	if err != nil {
+		return err
+	}
+
+	create_affiliate_index := `CREATE INDEX IF NOT EXISTS idx_form_submissions_affiliate_id ON form_submissions(affiliate_id)`
+	_, err = tx.ExecContext(ctx, create_affiliate_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddSubmissionAffiliate(ctx context.Context, tx *sql.Tx) error {
+	drop_affiliate_column := `ALTER TABLE form_submissions DROP COLUMN IF EXISTS affiliate_id`
+	_, err := tx.ExecContext(ctx, drop_affiliate_column)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
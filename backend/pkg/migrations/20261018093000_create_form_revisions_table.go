@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateFormRevisionsTable, downCreateFormRevisionsTable)
+}
+
+func upCreateFormRevisionsTable(ctx context.Context, tx *sql.Tx) error {
+	//---- create form_revisions table
+	create_form_revisions_table := `CREATE TABLE form_revisions (
+		id SERIAL PRIMARY KEY,
+		uuid UUID DEFAULT uuid_generate_v7() NOT NULL UNIQUE,
+		form_id INTEGER NOT NULL REFERENCES forms(id) ON DELETE CASCADE,
+		revision INTEGER NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		description TEXT,
+		status VARCHAR(20),
+		form_data JSONB NOT NULL,
+		created_at TIMESTAMP DEFAULT now(),
+		UNIQUE (form_id, revision)
+	)`
+	_, err := tx.ExecContext(ctx, create_form_revisions_table)
+	if err != nil {
+		return err
+	}
+
+	create_form_revisions_form_index := `CREATE INDEX IF NOT EXISTS idx_form_revisions_form_id ON form_revisions(form_id)`
+	_, err = tx.ExecContext(ctx, create_form_revisions_form_index)
+	if err != nil {
+		return err
+	}
+
+	//snapshot every existing form as its first revision
+	backfill_revisions := `
+		INSERT INTO form_revisions (form_id, revision, name, description, status, form_data, created_at)
+		SELECT id, 1, name, description, status, form_data, updated_at
+		FROM forms`
+	_, err = tx.ExecContext(ctx, backfill_revisions)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downCreateFormRevisionsTable(ctx context.Context, tx *sql.Tx) error {
+	drop_form_revisions := `DROP TABLE IF EXISTS form_revisions`
+	_, err := tx.ExecContext(ctx, drop_form_revisions)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateFormVersionsTable, downCreateFormVersionsTable)
+}
+
+func upCreateFormVersionsTable(ctx context.Context, tx *sql.Tx) error {
+	//---- create form_versions table, rows are immutable once published
+	create_form_versions_table := `CREATE TABLE form_versions (
+		id SERIAL PRIMARY KEY,
+		uuid UUID DEFAULT uuid_generate_v7() NOT NULL UNIQUE,
+		form_id INTEGER NOT NULL REFERENCES forms(id) ON DELETE CASCADE,
+		version INTEGER NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		description TEXT,
+		form_data JSONB NOT NULL,
+		published_at TIMESTAMP DEFAULT now(),
+		UNIQUE (form_id, version)
+	)`
+	_, err := tx.ExecContext(ctx, create_form_versions_table)
+	if err != nil {
+		return err
+	}
+
+	create_form_versions_form_index := `CREATE INDEX IF NOT EXISTS idx_form_versions_form_id ON form_versions(form_id)`
+	_, err = tx.ExecContext(ctx, create_form_versions_form_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- point forms and submissions at a published version
+	add_forms_published_version := `ALTER TABLE forms
+		ADD COLUMN published_version_id INTEGER REFERENCES form_versions(id) ON DELETE SET NULL`
+	_, err = tx.ExecContext(ctx, add_forms_published_version)
+	if err != nil {
+		return err
+	}
+
+	add_submissions_version := `ALTER TABLE form_submissions
+		ADD COLUMN form_version_id INTEGER REFERENCES form_versions(id) ON DELETE SET NULL`
+	_, err = tx.ExecContext(ctx, add_submissions_version)
+	if err != nil {
+		return err
+	}
+
+	create_submissions_version_index := `CREATE INDEX IF NOT EXISTS idx_form_submissions_form_version_id ON form_submissions(form_version_id)`
+	_, err = tx.ExecContext(ctx, create_submissions_version_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- publish forms that are already live so they keep being served
+	backfill_versions := `
+		INSERT INTO form_versions (form_id, version, name, description, form_data, published_at)
+		SELECT id, 1, name, description, form_data, updated_at
+		FROM forms
+		WHERE status = 'active'`
+	_, err = tx.ExecContext(ctx, backfill_versions)
+	if err != nil {
+		return err
+	}
+
+	backfill_forms := `
+		UPDATE forms f
+		SET published_version_id = fv.id
+		FROM form_versions fv
+		WHERE fv.form_id = f.id`
+	_, err = tx.ExecContext(ctx, backfill_forms)
+	if err != nil {
+		return err
+	}
+
+	backfill_submissions := `
+		UPDATE form_submissions fs
+		SET form_version_id = f.published_version_id
+		FROM forms f
+		WHERE fs.form_id = f.id`
+	_, err = tx.ExecContext(ctx, backfill_submissions)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downCreateFormVersionsTable(ctx context.Context, tx *sql.Tx) error {
+	drop_submissions_version := `ALTER TABLE form_submissions DROP COLUMN IF EXISTS form_version_id`
+	_, err := tx.ExecContext(ctx, drop_submissions_version)
+	if err != nil {
+		return err
+	}
+
+	drop_forms_published_version := `ALTER TABLE forms DROP COLUMN IF EXISTS published_version_id`
+	_, err = tx.ExecContext(ctx, drop_forms_published_version)
+	if err != nil {
+		return err
+	}
+
+	drop_form_versions := `DROP TABLE IF EXISTS form_versions`
+	_, err = tx.ExecContext(ctx, drop_form_versions)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
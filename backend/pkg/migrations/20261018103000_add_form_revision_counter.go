@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddFormRevisionCounter, downAddFormRevisionCounter)
+}
+
+func upAddFormRevisionCounter(ctx context.Context, tx *sql.Tx) error {
+	//---- current revision of the draft, used as the ETag for optimistic locking
+	add_revision_column := `ALTER TABLE forms ADD COLUMN revision INTEGER NOT NULL DEFAULT 1`
+	_, err := tx.ExecContext(ctx, add_revision_column)
+	if err != nil {
+		return err
+	}
+
+	backfill_revision := `
+		UPDATE forms f
+		SET revision = fr.revision
+		FROM (SELECT form_id, MAX(revision) AS revision FROM form_revisions GROUP BY form_id) fr
+		WHERE fr.form_id = f.id`
+	_, err = tx.ExecContext(ctx, backfill_revision)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddFormRevisionCounter(ctx context.Context, tx *sql.Tx) error {
+	drop_revision_column := `ALTER TABLE forms DROP COLUMN IF EXISTS revision`
+	_, err := tx.ExecContext(ctx, drop_revision_column)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
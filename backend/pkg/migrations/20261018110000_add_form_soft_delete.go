@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddFormSoftDelete, downAddFormSoftDelete)
+}
+
+func upAddFormSoftDelete(ctx context.Context, tx *sql.Tx) error {
+	//---- forms in the trash have deleted_at set until the sweeper purges them
+	add_deleted_at_column := `ALTER TABLE forms ADD COLUMN deleted_at TIMESTAMP`
+	_, err := tx.ExecContext(ctx, add_deleted_at_column)
+	if err != nil {
+		return err
+	}
+
+	create_deleted_at_index := `CREATE INDEX IF NOT EXISTS idx_forms_deleted_at ON forms(deleted_at) WHERE deleted_at IS NOT NULL`
+	_, err = tx.ExecContext(ctx, create_deleted_at_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddFormSoftDelete(ctx context.Context, tx *sql.Tx) error {
+	drop_deleted_at_column := `ALTER TABLE forms DROP COLUMN IF EXISTS deleted_at`
+	_, err := tx.ExecContext(ctx, drop_deleted_at_column)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateFormTemplatesTable, downCreateFormTemplatesTable)
+}
+
+func upCreateFormTemplatesTable(ctx context.Context, tx *sql.Tx) error {
+	//---- create form_templates table, private templates saved by a user,
+	//---- built-in templates live in pkg/templates and are never stored
+	create_form_templates_table := `CREATE TABLE form_templates (
+		id SERIAL PRIMARY KEY,
+		uuid UUID DEFAULT uuid_generate_v7() NOT NULL UNIQUE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		description TEXT,
+		form_data JSONB NOT NULL,
+		created_at TIMESTAMP DEFAULT now()
+	)`
+	_, err := tx.ExecContext(ctx, create_form_templates_table)
+	if err != nil {
+		return err
+	}
+
+	create_form_templates_user_index := `CREATE INDEX IF NOT EXISTS idx_form_templates_user_id ON form_templates(user_id)`
+	_, err = tx.ExecContext(ctx, create_form_templates_user_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downCreateFormTemplatesTable(ctx context.Context, tx *sql.Tx) error {
+	drop_form_templates := `DROP TABLE IF EXISTS form_templates`
+	_, err := tx.ExecContext(ctx, drop_form_templates)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
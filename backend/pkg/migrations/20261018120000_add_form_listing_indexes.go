@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddFormListingIndexes, downAddFormListingIndexes)
+}
+
+func upAddFormListingIndexes(ctx context.Context, tx *sql.Tx) error {
+	//---- keyset pagination of a user's forms, the default sort is newest first
+	create_listing_created_index := `CREATE INDEX IF NOT EXISTS idx_forms_listing_created
+		ON forms(user_id, created_at DESC, uuid DESC) WHERE deleted_at IS NULL`
+	_, err := tx.ExecContext(ctx, create_listing_created_index)
+	if err != nil {
+		return err
+	}
+
+	create_listing_updated_index := `CREATE INDEX IF NOT EXISTS idx_forms_listing_updated
+		ON forms(user_id, updated_at DESC, uuid DESC) WHERE deleted_at IS NULL`
+	_, err = tx.ExecContext(ctx, create_listing_updated_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddFormListingIndexes(ctx context.Context, tx *sql.Tx) error {
+	drop_listing_indexes := `DROP INDEX IF EXISTS idx_forms_listing_created, idx_forms_listing_updated`
+	_, err := tx.ExecContext(ctx, drop_listing_indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
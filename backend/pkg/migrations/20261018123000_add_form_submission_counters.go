@@ -0,0 +1,122 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddFormSubmissionCounters, downAddFormSubmissionCounters)
+}
+
+func upAddFormSubmissionCounters(ctx context.Context, tx *sql.Tx) error {
+	//---- counter columns, views is already a counter on forms
+	add_forms_submission_count := `ALTER TABLE forms ADD COLUMN submission_count INTEGER NOT NULL DEFAULT 0`
+	_, err := tx.ExecContext(ctx, add_forms_submission_count)
+	if err != nil {
+		return err
+	}
+
+	add_form_affiliates_submission_count := `ALTER TABLE form_affiliates ADD COLUMN submission_count INTEGER NOT NULL DEFAULT 0`
+	_, err = tx.ExecContext(ctx, add_form_affiliates_submission_count)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- keep the counters in step with form_submissions in the same
+	//---- transaction as the insert or delete, including cascades
+	create_count_function := `
+	CREATE OR REPLACE FUNCTION form_submissions_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'INSERT' THEN
+			UPDATE forms SET submission_count = submission_count + 1 WHERE id = NEW.form_id;
+			IF NEW.affiliate_id IS NOT NULL THEN
+				UPDATE form_affiliates SET submission_count = submission_count + 1
+				WHERE form_id = NEW.form_id AND affiliate_id = NEW.affiliate_id;
+			END IF;
+			RETURN NEW;
+		END IF;
+
+		UPDATE forms SET submission_count = GREATEST(submission_count - 1, 0) WHERE id = OLD.form_id;
+		IF OLD.affiliate_id IS NOT NULL THEN
+			UPDATE form_affiliates SET submission_count = GREATEST(submission_count - 1, 0)
+			WHERE form_id = OLD.form_id AND affiliate_id = OLD.affiliate_id;
+		END IF;
+		RETURN OLD;
+	END;
+	$$ LANGUAGE plpgsql`
+	_, err = tx.ExecContext(ctx, create_count_function)
+	if err != nil {
+		return err
+	}
+
+	create_count_trigger := `
+	CREATE TRIGGER form_submissions_count_trigger
+	AFTER INSERT OR DELETE ON form_submissions
+	FOR EACH ROW EXECUTE FUNCTION form_submissions_count()`
+	_, err = tx.ExecContext(ctx, create_count_trigger)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- backfill from the submissions that already exist
+	backfill_forms := `
+		UPDATE forms f
+		SET submission_count = c.count
+		FROM (SELECT form_id, COUNT(*) AS count FROM form_submissions GROUP BY form_id) c
+		WHERE c.form_id = f.id`
+	_, err = tx.ExecContext(ctx, backfill_forms)
+	if err != nil {
+		return err
+	}
+
+	backfill_form_affiliates := `
+		UPDATE form_affiliates fa
+		SET submission_count = c.count
+		FROM (
+			SELECT form_id, affiliate_id, COUNT(*) AS count
+			FROM form_submissions
+			WHERE affiliate_id IS NOT NULL
+			GROUP BY form_id, affiliate_id
+		) c
+		WHERE c.form_id = fa.form_id AND c.affiliate_id = fa.affiliate_id`
+	_, err = tx.ExecContext(ctx, backfill_form_affiliates)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddFormSubmissionCounters(ctx context.Context, tx *sql.Tx) error {
+	drop_count_trigger := `DROP TRIGGER IF EXISTS form_submissions_count_trigger ON form_submissions`
+	_, err := tx.ExecContext(ctx, drop_count_trigger)
+	if err != nil {
+		return err
+	}
+
+	drop_count_function := `DROP FUNCTION IF EXISTS form_submissions_count()`
+	_, err = tx.ExecContext(ctx, drop_count_function)
+	if err != nil {
+		return err
+	}
+
+	drop_form_affiliates_submission_count := `ALTER TABLE form_affiliates DROP COLUMN IF EXISTS submission_count`
+	_, err = tx.ExecContext(ctx, drop_form_affiliates_submission_count)
+	if err != nil {
+		return err
+	}
+
+	drop_forms_submission_count := `ALTER TABLE forms DROP COLUMN IF EXISTS submission_count`
+	_, err = tx.ExecContext(ctx, drop_forms_submission_count)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
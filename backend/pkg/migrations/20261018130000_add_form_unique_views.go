@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddFormUniqueViews, downAddFormUniqueViews)
+}
+
+func upAddFormUniqueViews(ctx context.Context, tx *sql.Tx) error {
+	//---- unique visitors per day alongside the raw views counter
+	add_unique_views_column := `ALTER TABLE forms ADD COLUMN unique_views INTEGER NOT NULL DEFAULT 0`
+	_, err := tx.ExecContext(ctx, add_unique_views_column)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- visitors seen per form per day, the hash is salted with a random
+	//---- salt kept in memory for the day so rows are only useful until the
+	//---- day ends and are cleared on the next flush after that
+	create_form_visitors_table := `CREATE TABLE form_visitors (
+		form_id INTEGER NOT NULL REFERENCES forms(id) ON DELETE CASCADE,
+		day DATE NOT NULL,
+		visitor_hash VARCHAR(64) NOT NULL,
+		PRIMARY KEY (form_id, day, visitor_hash)
+	)`
+	_, err = tx.ExecContext(ctx, create_form_visitors_table)
+	if err != nil {
+		return err
+	}
+
+	create_form_visitors_day_index := `CREATE INDEX IF NOT EXISTS idx_form_visitors_day ON form_visitors(day)`
+	_, err = tx.ExecContext(ctx, create_form_visitors_day_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddFormUniqueViews(ctx context.Context, tx *sql.Tx) error {
+	drop_form_visitors := `DROP TABLE IF EXISTS form_visitors`
+	_, err := tx.ExecContext(ctx, drop_form_visitors)
+	if err != nil {
+		return err
+	}
+
+	drop_unique_views_column := `ALTER TABLE forms DROP COLUMN IF EXISTS unique_views`
+	_, err = tx.ExecContext(ctx, drop_unique_views_column)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateFormDailyStatsTable, downCreateFormDailyStatsTable)
+}
+
+func upCreateFormDailyStatsTable(ctx context.Context, tx *sql.Tx) error {
+	//---- create form_daily_stats table, one row per form per day,
+	//---- affiliate_submissions maps affiliate uuid to that day's submissions
+	create_form_daily_stats_table := `CREATE TABLE form_daily_stats (
+		form_id INTEGER NOT NULL REFERENCES forms(id) ON DELETE CASCADE,
+		day DATE NOT NULL,
+		views INTEGER NOT NULL DEFAULT 0,
+		unique_views INTEGER NOT NULL DEFAULT 0,
+		starts INTEGER NOT NULL DEFAULT 0,
+		submissions INTEGER NOT NULL DEFAULT 0,
+		affiliate_submissions JSONB NOT NULL DEFAULT '{}'::jsonb,
+		PRIMARY KEY (form_id, day)
+	)`
+	_, err := tx.ExecContext(ctx, create_form_daily_stats_table)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- count submissions into the day they were made, deletes leave the
+	//---- history alone since the stats record what happened on the day
+	replace_count_function := `
+	CREATE OR REPLACE FUNCTION form_submissions_count() RETURNS trigger AS $$
+	DECLARE
+		affiliate_uuid TEXT;
+	BEGIN
+		IF TG_OP = 'INSERT' THEN
+			UPDATE forms SET submission_count = submission_count + 1 WHERE id = NEW.form_id;
+			IF NEW.affiliate_id IS NOT NULL THEN
+				UPDATE form_affiliates SET submission_count = submission_count + 1
+				WHERE form_id = NEW.form_id AND affiliate_id = NEW.affiliate_id;
+			END IF;
+
+			INSERT INTO form_daily_stats (form_id, day, submissions)
+			VALUES (NEW.form_id, NEW.submitted_at::date, 1)
+			ON CONFLICT (form_id, day) DO UPDATE SET submissions = form_daily_stats.submissions + 1;
+
+			IF NEW.affiliate_id IS NOT NULL THEN
+				SELECT uuid::text INTO affiliate_uuid FROM affiliates WHERE id = NEW.affiliate_id;
+				UPDATE form_daily_stats
+				SET affiliate_submissions = jsonb_set(
+					affiliate_submissions,
+					ARRAY[affiliate_uuid],
+					to_jsonb(COALESCE((affiliate_submissions->>affiliate_uuid)::int, 0) + 1)
+				)
+				WHERE form_id = NEW.form_id AND day = NEW.submitted_at::date;
+			END IF;
+
+			RETURN NEW;
+		END IF;
+
+		UPDATE forms SET submission_count = GREATEST(submission_count - 1, 0) WHERE id = OLD.form_id;
+		IF OLD.affiliate_id IS NOT NULL THEN
+			UPDATE form_affiliates SET submission_count = GREATEST(submission_count - 1, 0)
+			WHERE form_id = OLD.form_id AND affiliate_id = OLD.affiliate_id;
+		END IF;
+		RETURN OLD;
+	END;
+	$$ LANGUAGE plpgsql`
+	_, err = tx.ExecContext(ctx, replace_count_function)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- backfill submissions, views were only ever a lifetime total
+	backfill_submissions := `
+		INSERT INTO form_daily_stats (form_id, day, submissions)
+		SELECT form_id, submitted_at::date, COUNT(*)
+		FROM form_submissions
+		GROUP BY form_id, submitted_at::date`
+	_, err = tx.ExecContext(ctx, backfill_submissions)
+	if err != nil {
+		return err
+	}
+
+	backfill_affiliate_submissions := `
+		UPDATE form_daily_stats s
+		SET affiliate_submissions = c.counts
+		FROM (
+			SELECT form_id, day, jsonb_object_agg(affiliate_uuid, count) AS counts
+			FROM (
+				SELECT fs.form_id, fs.submitted_at::date AS day, a.uuid::text AS affiliate_uuid, COUNT(*) AS count
+				FROM form_submissions fs
+				JOIN affiliates a ON a.id = fs.affiliate_id
+				GROUP BY fs.form_id, day, a.uuid
+			) per_affiliate
+			GROUP BY form_id, day
+		) c
+		WHERE c.form_id = s.form_id AND c.day = s.day`
+	_, err = tx.ExecContext(ctx, backfill_affiliate_submissions)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downCreateFormDailyStatsTable(ctx context.Context, tx *sql.Tx) error {
+	restore_count_function := `
+	CREATE OR REPLACE FUNCTION form_submissions_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'INSERT' THEN
+			UPDATE forms SET submission_count = submission_count + 1 WHERE id = NEW.form_id;
+			IF NEW.affiliate_id IS NOT NULL THEN
+				UPDATE form_affiliates SET submission_count = submission_count + 1
+				WHERE form_id = NEW.form_id AND affiliate_id = NEW.affiliate_id;
+			END IF;
+			RETURN NEW;
+		END IF;
+
+		UPDATE forms SET submission_count = GREATEST(submission_count - 1, 0) WHERE id = OLD.form_id;
+		IF OLD.affiliate_id IS NOT NULL THEN
+			UPDATE form_affiliates SET submission_count = GREATEST(submission_count - 1, 0)
+			WHERE form_id = OLD.form_id AND affiliate_id = OLD.affiliate_id;
+		END IF;
+		RETURN OLD;
+	END;
+	$$ LANGUAGE plpgsql`
+	_, err := tx.ExecContext(ctx, restore_count_function)
+	if err != nil {
+		return err
+	}
+
+	drop_form_daily_stats := `DROP TABLE IF EXISTS form_daily_stats`
+	_, err = tx.ExecContext(ctx, drop_form_daily_stats)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
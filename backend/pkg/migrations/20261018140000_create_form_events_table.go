@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateFormEventsTable, downCreateFormEventsTable)
+}
+
+func upCreateFormEventsTable(ctx context.Context, tx *sql.Tx) error {
+	//---- create form_events table, progress reported by the public renderer.
+	//---- session_id is generated by the renderer per form load and is not
+	//---- tied to a user or submission
+	create_form_events_table := `CREATE TABLE form_events (
+		id BIGSERIAL PRIMARY KEY,
+		form_id INTEGER NOT NULL REFERENCES forms(id) ON DELETE CASCADE,
+		form_version_id INTEGER REFERENCES form_versions(id) ON DELETE SET NULL,
+		session_id UUID NOT NULL,
+		type VARCHAR(32) NOT NULL,
+		step_uuid VARCHAR(255),
+		created_at TIMESTAMP DEFAULT now()
+	)`
+	_, err := tx.ExecContext(ctx, create_form_events_table)
+	if err != nil {
+		return err
+	}
+
+	create_form_events_form_index := `CREATE INDEX IF NOT EXISTS idx_form_events_form_created ON form_events(form_id, created_at)`
+	_, err = tx.ExecContext(ctx, create_form_events_form_index)
+	if err != nil {
+		return err
+	}
+
+	// a session only starts a form once, repeats are ignored
+	create_form_events_start_index := `CREATE UNIQUE INDEX IF NOT EXISTS idx_form_events_session_start
+		ON form_events(form_id, session_id) WHERE type = 'form_started'`
+	_, err = tx.ExecContext(ctx, create_form_events_start_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downCreateFormEventsTable(ctx context.Context, tx *sql.Tx) error {
+	drop_form_events := `DROP TABLE IF EXISTS form_events`
+	_, err := tx.ExecContext(ctx, drop_form_events)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubmissionListingIndexes, downAddSubmissionListingIndexes)
+}
+
+func upAddSubmissionListingIndexes(ctx context.Context, tx *sql.Tx) error {
+	//---- keyset pagination of a form's submissions, the default sort is newest first
+	create_listing_submitted_index := `CREATE INDEX IF NOT EXISTS idx_form_submissions_listing_submitted
+		ON form_submissions(form_id, submitted_at DESC, uuid DESC)`
+	_, err := tx.ExecContext(ctx, create_listing_submitted_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddSubmissionListingIndexes(ctx context.Context, tx *sql.Tx) error {
+	drop_listing_indexes := `DROP INDEX IF EXISTS idx_form_submissions_listing_submitted`
+	_, err := tx.ExecContext(ctx, drop_listing_indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
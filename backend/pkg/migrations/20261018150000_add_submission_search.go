@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubmissionSearch, downAddSubmissionSearch)
+}
+
+func upAddSubmissionSearch(ctx context.Context, tx *sql.Tx) error {
+	//---- the text search configuration a form's submissions are indexed with
+	add_form_search_language := `ALTER TABLE forms ADD COLUMN IF NOT EXISTS search_language VARCHAR(32) NOT NULL DEFAULT 'simple'`
+	_, err := tx.ExecContext(ctx, add_form_search_language)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	//---- a generated column cannot look up the form so each submission carries
+	// its form's configuration, it is rewritten when the form's setting changes
+	add_submission_search_language := `ALTER TABLE form_submissions ADD COLUMN IF NOT EXISTS search_language REGCONFIG NOT NULL DEFAULT 'simple'`
+	_, err = tx.ExecContext(ctx, add_submission_search_language)
+	if err != nil {
+		return err
+	}
+
+	add_submission_search_vector := `ALTER TABLE form_submissions ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector(search_language, COALESCE(full_name, '')), 'A') ||
+			setweight(to_tsvector(search_language, COALESCE(email, '')), 'A') ||
+			setweight(jsonb_to_tsvector(search_language, submission_data, '["string"]'), 'B')
+		) STORED`
+	_, err = tx.ExecContext(ctx, add_submission_search_vector)
+	if err != nil {
+		return err
+	}
+
+	create_submission_search_index := `CREATE INDEX IF NOT EXISTS idx_form_submissions_search ON form_submissions USING GIN (search_vector)`
+	_, err = tx.ExecContext(ctx, create_submission_search_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddSubmissionSearch(ctx context.Context, tx *sql.Tx) error {
+	drop_submission_search := `ALTER TABLE form_submissions DROP COLUMN IF EXISTS search_vector, DROP COLUMN IF EXISTS search_language`
+	_, err := tx.ExecContext(ctx, drop_submission_search)
+	if err != nil {
+		return err
+	}
+
+	drop_form_search_language := `ALTER TABLE forms DROP COLUMN IF EXISTS search_language`
+	_, err = tx.ExecContext(ctx, drop_form_search_language)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
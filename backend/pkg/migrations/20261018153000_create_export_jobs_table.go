@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateExportJobsTable, downCreateExportJobsTable)
+}
+
+func upCreateExportJobsTable(ctx context.Context, tx *sql.Tx) error {
+	//---- create export_jobs table, submission exports run in the background.
+	//---- form and user are kept nullable so an expired job's file can still be
+	//---- found and deleted after the form is purged
+	create_export_jobs_table := `CREATE TABLE export_jobs (
+		id SERIAL PRIMARY KEY,
+		uuid UUID DEFAULT uuid_generate_v7() NOT NULL UNIQUE,
+		form_id INTEGER REFERENCES forms(id) ON DELETE SET NULL,
+		user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		format VARCHAR(16) NOT NULL,
+		sheet_per_step BOOLEAN NOT NULL DEFAULT false,
+		filter JSONB NOT NULL DEFAULT '{}'::jsonb,
+		status VARCHAR(16) NOT NULL DEFAULT 'queued',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		processed INTEGER NOT NULL DEFAULT 0,
+		total INTEGER,
+		storage_key VARCHAR(255),
+		error TEXT,
+		started_at TIMESTAMP,
+		finished_at TIMESTAMP,
+		expires_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT now(),
+		updated_at TIMESTAMP DEFAULT now()
+	)`
+	_, err := tx.ExecContext(ctx, create_export_jobs_table)
+	if err != nil {
+		return err
+	}
+
+	// workers claim the oldest queued job and requeue running ones that stall
+	create_export_jobs_status_index := `CREATE INDEX IF NOT EXISTS idx_export_jobs_status ON export_jobs(status, id)`
+	_, err = tx.ExecContext(ctx, create_export_jobs_status_index)
+	if err != nil {
+		return err
+	}
+
+	create_export_jobs_expires_index := `CREATE INDEX IF NOT EXISTS idx_export_jobs_expires ON export_jobs(expires_at) WHERE expires_at IS NOT NULL`
+	_, err = tx.ExecContext(ctx, create_export_jobs_expires_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downCreateExportJobsTable(ctx context.Context, tx *sql.Tx) error {
+	drop_export_jobs := `DROP TABLE IF EXISTS export_jobs`
+	_, err := tx.ExecContext(ctx, drop_export_jobs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddFormEventsStepIndex, downAddFormEventsStepIndex)
+}
+
+func upAddFormEventsStepIndex(ctx context.Context, tx *sql.Tx) error {
+	//---- a session views and completes each step once, repeats are ignored.
+	//---- Only the first of each is used by the funnel so later duplicates
+	//---- are removed before the index is built
+	delete_duplicate_step_events := `DELETE FROM form_events e
+		USING form_events first
+		WHERE e.step_uuid IS NOT NULL
+		AND first.form_id = e.form_id
+		AND first.session_id = e.session_id
+		AND first.type = e.type
+		AND first.step_uuid = e.step_uuid
+		AND (first.created_at, first.id) < (e.created_at, e.id)`
+	_, err := tx.ExecContext(ctx, delete_duplicate_step_events)
+	if err != nil {
+		return err
+	}
+
+	create_form_events_step_index := `CREATE UNIQUE INDEX IF NOT EXISTS idx_form_events_session_step
+		ON form_events(form_id, session_id, type, step_uuid) WHERE step_uuid IS NOT NULL`
+	_, err = tx.ExecContext(ctx, create_form_events_step_index)
+	if err != nil {
+		return err
+	}
+	//---- end
+
+	return nil
+}
+
+func downAddFormEventsStepIndex(ctx context.Context, tx *sql.Tx) error {
+	drop_form_events_step_index := `DROP INDEX IF EXISTS idx_form_events_session_step`
+	_, err := tx.ExecContext(ctx, drop_form_events_step_index)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
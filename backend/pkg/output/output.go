@@ -42,3 +42,8 @@ func WriteJson(w http.ResponseWriter, r *http.Request, status int, v any) error
 func SuccessResponse(w http.ResponseWriter, r *http.Request, v any) (int, error) {
 	return NilError, WriteJson(w, r, http.StatusOK, v)
 }
+
+// ErrorResponse is for errors that need more than a message, eg. per field validation errors
+func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, v any) (int, error) {
+	return NilError, WriteJson(w, r, status, v)
+}
@@ -0,0 +1,167 @@
+package portable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	form_repo "formaura/pkg/repositories/form"
+	theme_repo "formaura/pkg/repositories/themes"
+	"formaura/pkg/validate"
+	"time"
+)
+
+// Format identifies a Formaura form document
+const Format = "formaura.form"
+
+// SchemaVersion is the version written by Export. Bump it whenever the
+// document shape changes and add an upgrader from the previous version.
+const SchemaVersion = 2
+
+// upgraders[n] rewrites a raw version n document into version n+1
+var upgraders = map[int]func(doc map[string]any) error{
+	// version 1 only reserved the theme, it was always null and its shape was
+	// never defined, so whatever an old document holds there is dropped
+	1: func(doc map[string]any) error {
+		delete(doc, "theme")
+		return nil
+	},
+}
+
+var (
+	ErrInvalidDocument = errors.New("portable: invalid document")
+	ErrUnknownFormat   = errors.New("portable: not a formaura form document")
+	ErrUnsupported     = errors.New("portable: unsupported schema version")
+)
+
+type Meta struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Status      string  `json:"status"`
+}
+
+// Document is a self-describing export of a single form that can be imported
+// into any account or environment
+type Document struct {
+	Format        string             `json:"format"`
+	SchemaVersion int                `json:"schema_version"`
+	ExportedAt    time.Time          `json:"exported_at"`
+	Form          Meta               `json:"form"`
+	FormData      form_repo.FormData `json:"form_data"`
+	// the form's theme, kept out of FormData in the document
+	Theme *theme_repo.Model `json:"theme"`
+}
+
+// InvalidFormDataError is returned by Prepare when the form data fails linting
+type InvalidFormDataError struct {
+	Issues validate.Issues
+}
+
+func (e *InvalidFormDataError) Error() string {
+	return "portable: form data invalid"
+}
+
+// Export builds a document from a form
+func Export(form *form_repo.FormModel) (*Document, error) {
+	doc := &Document{
+		Format:        Format,
+		SchemaVersion: SchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Form: Meta{
+			Name:        form.Name,
+			Description: form.Description,
+			Status:      form.Status,
+		},
+	}
+
+	if err := form.UnmarshalFormData(&doc.FormData); err != nil {
+		return nil, fmt.Errorf("portable.Export: %w", err)
+	}
+
+	if doc.FormData.Steps == nil {
+		doc.FormData.Steps = []form_repo.Step{}
+	}
+
+	doc.Theme = doc.FormData.Theme
+	doc.FormData.Theme = nil
+
+	return doc, nil
+}
+
+// Decode parses a document, upgrading it to the current schema version
+func Decode(b []byte) (*Document, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+
+	if raw["format"] != Format {
+		return nil, ErrUnknownFormat
+	}
+
+	version, ok := raw["schema_version"].(float64)
+	if !ok || version != float64(int(version)) {
+		return nil, fmt.Errorf("%w: schema_version must be an integer", ErrInvalidDocument)
+	}
+
+	if err := upgrade(raw, int(version)); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+
+	if doc.Form.Name == "" {
+		return nil, fmt.Errorf("%w: form name is required", ErrInvalidDocument)
+	}
+
+	return &doc, nil
+}
+
+func upgrade(raw map[string]any, version int) error {
+	if version < 1 || version > SchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupported, version)
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		upgrader, ok := upgraders[v]
+		if !ok {
+			return fmt.Errorf("%w: no upgrade from %d", ErrUnsupported, v)
+		}
+		if err := upgrader(raw); err != nil {
+			return fmt.Errorf("portable: upgrading from %d: %w", v, err)
+		}
+		raw["schema_version"] = v + 1
+	}
+
+	return nil
+}
+
+// Prepare lints the document's form data and returns a copy with fresh UUIDs
+// ready to be created as a new draft, with the theme moved back into it. Lint
+// warnings are returned alongside.
+func Prepare(doc *Document) (*form_repo.FormData, validate.Issues, error) {
+	issues := validate.LintFormData(&doc.FormData)
+
+	if issues.HasErrors() {
+		return nil, issues, &InvalidFormDataError{Issues: issues}
+	}
+
+	formData, err := form_repo.CloneWithNewUUIDs(&doc.FormData)
+	if err != nil {
+		return nil, issues, fmt.Errorf("portable.Prepare: %w", err)
+	}
+
+	if doc.Theme != nil {
+		theme := *doc.Theme
+		formData.Theme = &theme
+	}
+
+	return formData, issues, nil
+}
@@ -0,0 +1,127 @@
+package portable_test
+
+import (
+	"encoding/json"
+	"errors"
+	"formaura/pkg/portable"
+	form_repo "formaura/pkg/repositories/form"
+	theme_repo "formaura/pkg/repositories/themes"
+	"testing"
+)
+
+func testForm(t *testing.T) *form_repo.FormModel {
+	formData := form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Title: "Details", Fields: []form_repo.Field{
+				{UUID: "f1", Type: "text", Name: "full_name", Label: "Name", Required: true},
+			}},
+		},
+		Theme: &theme_repo.Model{BackgroundColor: "#ffffff", PrimaryColor: "#6d28d9"},
+	}
+
+	b, err := json.Marshal(formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &form_repo.FormModel{Name: "Leads", Status: form_repo.StatusActive, FormData: b}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	doc, err := portable.Export(testForm(t))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := portable.Decode(b)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if decoded.Form.Name != "Leads" || decoded.SchemaVersion != portable.SchemaVersion {
+		t.Errorf("unexpected document %+v", decoded)
+	}
+
+	formData, _, err := portable.Prepare(decoded)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if formData.Steps[0].UUID == "s1" || formData.Steps[0].Fields[0].UUID == "f1" {
+		t.Errorf("expected fresh uuids, got %+v", formData.Steps[0])
+	}
+
+	if decoded.FormData.Theme != nil {
+		t.Errorf("expected the theme outside form_data, got %+v", decoded.FormData.Theme)
+	}
+
+	if formData.Theme == nil || formData.Theme.PrimaryColor != "#6d28d9" {
+		t.Errorf("expected the theme to be imported, got %+v", formData.Theme)
+	}
+}
+
+func TestDecodeUpgradesVersion1(t *testing.T) {
+	// version 1 reserved the theme without defining it
+	doc := `{
+		"format": "formaura.form",
+		"schema_version": 1,
+		"form": {"name": "Leads", "status": "active"},
+		"form_data": {"steps": []},
+		"theme": "dark"
+	}`
+
+	decoded, err := portable.Decode([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if decoded.SchemaVersion != portable.SchemaVersion {
+		t.Errorf("expected version %d, got %d", portable.SchemaVersion, decoded.SchemaVersion)
+	}
+
+	if decoded.Form.Name != "Leads" || decoded.Theme != nil {
+		t.Errorf("unexpected document %+v", decoded)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	cases := map[string]struct {
+		doc string
+		err error
+	}{
+		"not json":       {`{`, portable.ErrInvalidDocument},
+		"unknown format": {`{"format": "other", "schema_version": 1}`, portable.ErrUnknownFormat},
+		"future version": {`{"format": "formaura.form", "schema_version": 99, "form": {"name": "x"}}`, portable.ErrUnsupported},
+		"missing name":   {`{"format": "formaura.form", "schema_version": 1, "form": {}}`, portable.ErrInvalidDocument},
+	}
+
+	for name, c := range cases {
+		if _, err := portable.Decode([]byte(c.doc)); !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+}
+
+func TestPrepareRejectsInvalidFormData(t *testing.T) {
+	doc := &portable.Document{
+		FormData: form_repo.FormData{
+			Steps: []form_repo.Step{
+				{UUID: "s1", Fields: []form_repo.Field{
+					{UUID: "f1", Type: "not-a-type", Name: "a"},
+				}},
+			},
+		},
+	}
+
+	_, _, err := portable.Prepare(doc)
+
+	var invalid *portable.InvalidFormDataError
+	if !errors.As(err, &invalid) || len(invalid.Issues) == 0 {
+		t.Errorf("expected lint issues, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows each key a number of requests per fixed window. Counts are
+// kept in memory and all reset when a window ends, so it only limits within a
+// single instance and never holds more than a window's worth of keys.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:  limit,
+		window: window,
+		counts: map[string]int{},
+	}
+}
+
+// Allow counts a request for key and reports whether it is within the limit
+func (l *Limiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.start) >= l.window || now.Before(l.start) {
+		clear(l.counts)
+		l.start = now
+	}
+
+	l.counts[key]++
+
+	return l.counts[key] <= l.limit
+}
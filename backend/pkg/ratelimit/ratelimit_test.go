@@ -0,0 +1,26 @@
+package ratelimit_test
+
+import (
+	"formaura/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := ratelimit.New(2, time.Minute)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	for i, want := range []bool{true, true, false, false} {
+		if got := limiter.Allow("1.2.3.4", now.Add(time.Duration(i)*time.Second)); got != want {
+			t.Errorf("request %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	if !limiter.Allow("5.6.7.8", now.Add(5*time.Second)) {
+		t.Error("expected another key to have its own limit")
+	}
+
+	if !limiter.Allow("1.2.3.4", now.Add(time.Minute)) {
+		t.Error("expected the limit to reset with the next window")
+	}
+}
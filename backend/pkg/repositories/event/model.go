@@ -0,0 +1,30 @@
+package event_repo
+
+import "time"
+
+// Event types reported by the public renderer
+const (
+	TypeFormStarted   = "form_started"
+	TypeStepViewed    = "step_viewed"
+	TypeStepCompleted = "step_completed"
+)
+
+var ValidTypes = []string{TypeFormStarted, TypeStepViewed, TypeStepCompleted}
+
+type Model struct {
+	ID            int64     `json:"-" db:"id"`
+	FormID        int       `json:"-" db:"form_id"`
+	FormVersionID *int      `json:"-" db:"form_version_id"`
+	SessionID     string    `json:"session_id" db:"session_id"`
+	Type          string    `json:"type" db:"type"`
+	StepUUID      *string   `json:"step_uuid" db:"step_uuid"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// StepStats is the raw funnel activity for one step over a date range
+type StepStats struct {
+	StepUUID      string   `db:"step_uuid"`
+	Viewed        int      `db:"viewed"`
+	Completed     int      `db:"completed"`
+	MedianSeconds *float64 `db:"median_seconds"`
+}
@@ -0,0 +1,117 @@
+package event_repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type Repository interface {
+	Create(ctx context.Context, formId int, versionId int, sessionId string, eventType string, stepUUID *string) error
+	CountStarts(ctx context.Context, formId int, from, before time.Time) (int, error)
+	GetStepStats(ctx context.Context, formId int, from, before time.Time) ([]*StepStats, error)
+}
+
+type EventRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEventRepo(db *pgxpool.Pool) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// Create records an event, a session's form_started and each of its step
+// events are only recorded once. A new form_started also adds to the day's
+// starts in form_daily_stats.
+func (r *EventRepository) Create(ctx context.Context, formId int, versionId int, sessionId string, eventType string, stepUUID *string) error {
+	// days are UTC, see views.Day
+	now := time.Now().UTC()
+
+	query := `
+		INSERT INTO form_events (form_id, form_version_id, session_id, type, step_uuid, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING
+	`
+
+	add_start := `
+		INSERT INTO form_daily_stats (form_id, day, starts)
+		VALUES ($1, $2::date, 1)
+		ON CONFLICT (form_id, day) DO UPDATE SET starts = form_daily_stats.starts + 1
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("event.Create begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, formId, versionId, sessionId, eventType, stepUUID, now)
+	if err != nil {
+		return fmt.Errorf("event.Create query: %w", err)
+	}
+
+	if eventType == TypeFormStarted && tag.RowsAffected() > 0 {
+		if _, err := tx.Exec(ctx, add_start, formId, now); err != nil {
+			return fmt.Errorf("event.Create starts: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("event.Create commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EventRepository) CountStarts(ctx context.Context, formId int, from, before time.Time) (int, error) {
+	var started int
+
+	query := `
+	SELECT COUNT(*)
+	FROM form_events
+	WHERE form_id = $1 AND type = 'form_started' AND created_at >= $2 AND created_at < $3`
+
+	err := r.db.QueryRow(ctx, query, formId, from, before).Scan(&started)
+	if err != nil {
+		return 0, fmt.Errorf("event.CountStarts query: %w", err)
+	}
+
+	return started, nil
+}
+
+// GetStepStats counts the sessions that viewed and completed each step, and
+// the median time between a session first viewing and first completing it.
+// Completions are only counted for sessions that also viewed the step so
+// completed never exceeds viewed.
+func (r *EventRepository) GetStepStats(ctx context.Context, formId int, from, before time.Time) ([]*StepStats, error) {
+	stats := []*StepStats{}
+
+	query := `
+	SELECT
+		step_uuid,
+		COUNT(*) FILTER (WHERE viewed_at IS NOT NULL) AS viewed,
+		COUNT(*) FILTER (WHERE viewed_at IS NOT NULL AND completed_at IS NOT NULL) AS completed,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM completed_at - viewed_at))
+			FILTER (WHERE completed_at >= viewed_at) AS median_seconds
+	FROM (
+		SELECT
+			step_uuid,
+			session_id,
+			MIN(created_at) FILTER (WHERE type = 'step_viewed') AS viewed_at,
+			MIN(created_at) FILTER (WHERE type = 'step_completed') AS completed_at
+		FROM form_events
+		WHERE form_id = $1 AND step_uuid IS NOT NULL AND created_at >= $2 AND created_at < $3
+		GROUP BY step_uuid, session_id
+	) sessions
+	GROUP BY step_uuid`
+
+	err := pgxscan.Select(ctx, r.db, &stats, query, formId, from, before)
+	if err != nil {
+		return nil, fmt.Errorf("event.GetStepStats query: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,58 @@
+package export_repo
+
+import (
+	"encoding/json"
+	submission_repo "formaura/pkg/repositories/submission"
+	"time"
+)
+
+// Job statuses, queued jobs are claimed by a worker and end done or failed
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// MaxAttempts is how many times a job that stalls, eg. because its worker
+// stopped, is run before it is failed
+const MaxAttempts = 3
+
+type Model struct {
+	ID           int             `json:"-" db:"id"`
+	UUID         string          `json:"uuid" db:"uuid"`
+	FormID       *int            `json:"-" db:"form_id"`
+	UserID       *int            `json:"-" db:"user_id"`
+	Format       string          `json:"format" db:"format"`
+	SheetPerStep bool            `json:"sheet_per_step" db:"sheet_per_step"`
+	Filter       json.RawMessage `json:"-" db:"filter"`
+	Status       string          `json:"status" db:"status"`
+	Attempts     int             `json:"-" db:"attempts"`
+	Processed    int             `json:"processed" db:"processed"`
+	Total        *int            `json:"total" db:"total"`
+	StorageKey   *string         `json:"-" db:"storage_key"`
+	Error        *string         `json:"error" db:"error"`
+	StartedAt    *time.Time      `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time      `json:"finished_at" db:"finished_at"`
+	ExpiresAt    *time.Time      `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// GetFilter decodes the submission filter the job was queued with
+func (m *Model) GetFilter() (*submission_repo.Filter, error) {
+	var filter submission_repo.Filter
+	err := json.Unmarshal(m.Filter, &filter)
+	return &filter, err
+}
+
+// Progress is the fraction of submissions written, 0 until the total is known
+func (m *Model) Progress() float64 {
+	if m.Status == StatusDone {
+		return 1
+	}
+	if m.Total == nil || *m.Total == 0 {
+		return 0
+	}
+	return min(float64(m.Processed)/float64(*m.Total), 1)
+}
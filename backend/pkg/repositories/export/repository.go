@@ -0,0 +1,231 @@
+package export_repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"formaura/pkg/db"
+	submission_repo "formaura/pkg/repositories/submission"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type Repository interface {
+	Create(ctx context.Context, formId int, userId int, format string, sheetPerStep bool, filter submission_repo.Filter) (*Model, error)
+	GetByUUID(ctx context.Context, uuid string) (*Model, error)
+	ClaimNext(ctx context.Context) (*Model, error)
+	SetTotal(ctx context.Context, id int, total int) error
+	UpdateProgress(ctx context.Context, id int, processed int) error
+	Complete(ctx context.Context, id int, processed int, storageKey string, expiresAt time.Time) error
+	Fail(ctx context.Context, id int, reason string, expiresAt time.Time) error
+	Requeue(ctx context.Context, id int) error
+	RequeueStalled(ctx context.Context, stalledFor time.Duration, expiresAt time.Time) (int64, error)
+	DeleteExpired(ctx context.Context, now time.Time) ([]string, error)
+}
+
+type ExportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExportRepo(db *pgxpool.Pool) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+func (r *ExportRepository) Create(ctx context.Context, formId int, userId int, format string, sheetPerStep bool, filter submission_repo.Filter) (*Model, error) {
+	var job Model
+
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return nil, fmt.Errorf("export.Create filter: %w", err)
+	}
+
+	query := `
+		INSERT INTO export_jobs (form_id, user_id, format, sheet_per_step, filter)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, r.db, &job, query, formId, userId, format, sheetPerStep, filterJSON)
+	if err != nil {
+		return nil, fmt.Errorf("export.Create query: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (r *ExportRepository) GetByUUID(ctx context.Context, uuid string) (*Model, error) {
+	var job Model
+
+	query := `SELECT * FROM export_jobs WHERE uuid=$1`
+
+	err := pgxscan.Get(ctx, r.db, &job, query, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("export.GetByUUID query: %w", err)
+	}
+
+	return &job, nil
+}
+
+// ClaimNext marks the oldest queued job as running and returns it, or nil when
+// the queue is empty. Jobs locked by another worker are skipped so any number
+// of workers can share the queue.
+func (r *ExportRepository) ClaimNext(ctx context.Context) (*Model, error) {
+	var job Model
+
+	query := `
+		UPDATE export_jobs
+		SET status = 'running', attempts = attempts + 1, started_at = now(), updated_at = now()
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'queued'
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+
+	err := pgxscan.Get(ctx, r.db, &job, query)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("export.ClaimNext query: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (r *ExportRepository) SetTotal(ctx context.Context, id int, total int) error {
+	query := `UPDATE export_jobs SET total = $2, updated_at = now() WHERE id = $1`
+
+	_, err := r.db.Exec(ctx, query, id, total)
+	if err != nil {
+		return fmt.Errorf("export.SetTotal query: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProgress also touches updated_at, which is how a running job shows it
+// has not stalled
+func (r *ExportRepository) UpdateProgress(ctx context.Context, id int, processed int) error {
+	query := `UPDATE export_jobs SET processed = $2, updated_at = now() WHERE id = $1`
+
+	_, err := r.db.Exec(ctx, query, id, processed)
+	if err != nil {
+		return fmt.Errorf("export.UpdateProgress query: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ExportRepository) Complete(ctx context.Context, id int, processed int, storageKey string, expiresAt time.Time) error {
+	query := `
+		UPDATE export_jobs
+		SET status = 'done', processed = $2, storage_key = $3, expires_at = $4, error = NULL,
+			finished_at = now(), updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, id, processed, storageKey, expiresAt)
+	if err != nil {
+		return fmt.Errorf("export.Complete query: %w", err)
+	}
+
+	return nil
+}
+
+// Fail records why the job failed, the row is kept until expiresAt so the
+// reason can be reported
+func (r *ExportRepository) Fail(ctx context.Context, id int, reason string, expiresAt time.Time) error {
+	query := `
+		UPDATE export_jobs
+		SET status = 'failed', error = $2, expires_at = $3, finished_at = now(), updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, id, reason, expiresAt)
+	if err != nil {
+		return fmt.Errorf("export.Fail query: %w", err)
+	}
+
+	return nil
+}
+
+// Requeue puts back a job its worker gave up on without it failing, eg. on
+// shutdown, the attempt is not counted
+func (r *ExportRepository) Requeue(ctx context.Context, id int) error {
+	query := `
+		UPDATE export_jobs
+		SET status = 'queued', attempts = GREATEST(attempts - 1, 0), processed = 0, updated_at = now()
+		WHERE id = $1 AND status = 'running'
+	`
+
+	_, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("export.Requeue query: %w", err)
+	}
+
+	return nil
+}
+
+// RequeueStalled queues running jobs again that have not made progress for
+// stalledFor, their worker most likely died. Jobs out of attempts are failed
+// and kept until expiresAt. updated_at is set by the database so the stall is
+// measured against its clock.
+func (r *ExportRepository) RequeueStalled(ctx context.Context, stalledFor time.Duration, expiresAt time.Time) (int64, error) {
+	query := `
+		UPDATE export_jobs
+		SET status = CASE WHEN attempts < $2 THEN 'queued' ELSE 'failed' END,
+			error = CASE WHEN attempts < $2 THEN NULL ELSE 'Export stopped responding' END,
+			finished_at = CASE WHEN attempts < $2 THEN NULL ELSE now() END,
+			expires_at = CASE WHEN attempts < $2 THEN NULL ELSE $3::timestamp END,
+			processed = 0,
+			updated_at = now()
+		WHERE status = 'running' AND updated_at < now() - make_interval(secs => $1)
+	`
+
+	tag, err := r.db.Exec(ctx, query, stalledFor.Seconds(), MaxAttempts, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("export.RequeueStalled query: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DeleteExpired removes finished jobs past their expiry and returns the
+// storage keys of their files so they can be deleted too
+func (r *ExportRepository) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	keys := []string{}
+
+	query := `
+		DELETE FROM export_jobs
+		WHERE expires_at < $1
+		RETURNING storage_key
+	`
+
+	rows, err := r.db.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("export.DeleteExpired query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key *string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("export.DeleteExpired scan: %w", err)
+		}
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export.DeleteExpired rows: %w", err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,190 @@
+package form_repo
+
+import "reflect"
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+type StepChange struct {
+	UUID       string   `json:"uuid"`
+	Title      string   `json:"title"`
+	Change     string   `json:"change"`
+	Properties []string `json:"properties,omitempty"`
+}
+
+type FieldChange struct {
+	UUID       string   `json:"uuid"`
+	StepUUID   string   `json:"step_uuid"`
+	Label      string   `json:"label"`
+	Change     string   `json:"change"`
+	Properties []string `json:"properties,omitempty"`
+}
+
+type FormDataDiff struct {
+	Steps       []StepChange  `json:"steps"`
+	Fields      []FieldChange `json:"fields"`
+	HeroChanged bool          `json:"hero_changed"`
+}
+
+type indexedStep struct {
+	// position among the steps that exist in both versions
+	index int
+	step  *Step
+}
+
+type indexedField struct {
+	// position among the fields of the step that exist in both versions
+	index    int
+	stepUUID string
+	field    *Field
+}
+
+// indexFormData indexes steps and fields by UUID, positions only count entries
+// also present in other so that an insert or removal does not read as a move
+func indexFormData(d, other *FormData) (map[string]indexedStep, map[string]indexedField) {
+	otherSteps := map[string]bool{}
+	otherFields := map[string]string{}
+
+	for _, step := range other.Steps {
+		otherSteps[step.UUID] = true
+		for _, field := range step.Fields {
+			otherFields[field.UUID] = step.UUID
+		}
+	}
+
+	steps := map[string]indexedStep{}
+	fields := map[string]indexedField{}
+
+	stepIndex := 0
+	for i := range d.Steps {
+		step := &d.Steps[i]
+		steps[step.UUID] = indexedStep{index: stepIndex, step: step}
+		if otherSteps[step.UUID] {
+			stepIndex++
+		}
+
+		fieldIndex := 0
+		for j := range step.Fields {
+			field := &step.Fields[j]
+			fields[field.UUID] = indexedField{index: fieldIndex, stepUUID: step.UUID, field: field}
+			if otherFields[field.UUID] == step.UUID {
+				fieldIndex++
+			}
+		}
+	}
+
+	return steps, fields
+}
+
+// DiffFormData compares two versions of a form by step and field UUID.
+// Removed entries are listed in the order of the old version, everything else
+// in the order of the new one.
+func DiffFormData(from, to *FormData) *FormDataDiff {
+	diff := &FormDataDiff{
+		Steps:       []StepChange{},
+		Fields:      []FieldChange{},
+		HeroChanged: !reflect.DeepEqual(from.Hero, to.Hero),
+	}
+
+	fromSteps, fromFields := indexFormData(from, to)
+	toSteps, toFields := indexFormData(to, from)
+
+	for _, step := range from.Steps {
+		if _, ok := toSteps[step.UUID]; !ok {
+			diff.Steps = append(diff.Steps, StepChange{UUID: step.UUID, Title: step.Title, Change: ChangeRemoved})
+		}
+	}
+
+	for _, step := range from.Steps {
+		for _, field := range step.Fields {
+			if _, ok := toFields[field.UUID]; !ok {
+				diff.Fields = append(diff.Fields, FieldChange{UUID: field.UUID, StepUUID: step.UUID, Label: field.Label, Change: ChangeRemoved})
+			}
+		}
+	}
+
+	for _, step := range to.Steps {
+		old, ok := fromSteps[step.UUID]
+
+		if !ok {
+			diff.Steps = append(diff.Steps, StepChange{UUID: step.UUID, Title: step.Title, Change: ChangeAdded})
+		} else if props := diffStep(old.step, &step, old.index != toSteps[step.UUID].index); len(props) > 0 {
+			diff.Steps = append(diff.Steps, StepChange{UUID: step.UUID, Title: step.Title, Change: ChangeChanged, Properties: props})
+		}
+
+		for _, field := range step.Fields {
+			old, ok := fromFields[field.UUID]
+
+			if !ok {
+				diff.Fields = append(diff.Fields, FieldChange{UUID: field.UUID, StepUUID: step.UUID, Label: field.Label, Change: ChangeAdded})
+				continue
+			}
+
+			moved := old.stepUUID != step.UUID || old.index != toFields[field.UUID].index
+			if props := diffField(old.field, &field, moved); len(props) > 0 {
+				diff.Fields = append(diff.Fields, FieldChange{UUID: field.UUID, StepUUID: step.UUID, Label: field.Label, Change: ChangeChanged, Properties: props})
+			}
+		}
+	}
+
+	return diff
+}
+
+func diffStep(a, b *Step, moved bool) []string {
+	props := []string{}
+
+	if moved {
+		props = append(props, "position")
+	}
+	if a.Title != b.Title {
+		props = append(props, "title")
+	}
+	if a.Description != b.Description {
+		props = append(props, "description")
+	}
+	if !reflect.DeepEqual(a.Condition, b.Condition) {
+		props = append(props, "condition")
+	}
+
+	return props
+}
+
+func diffField(a, b *Field, moved bool) []string {
+	props := []string{}
+
+	if moved {
+		props = append(props, "position")
+	}
+	if a.Type != b.Type {
+		props = append(props, "type")
+	}
+	if a.Name != b.Name {
+		props = append(props, "name")
+	}
+	if a.Label != b.Label {
+		props = append(props, "label")
+	}
+	if a.Placeholder != b.Placeholder {
+		props = append(props, "placeholder")
+	}
+	if a.Required != b.Required {
+		props = append(props, "required")
+	}
+	if !reflect.DeepEqual(a.Validation, b.Validation) {
+		props = append(props, "validation")
+	}
+	if !reflect.DeepEqual(a.Options, b.Options) {
+		props = append(props, "options")
+	}
+	if a.DefaultValue != b.DefaultValue {
+		props = append(props, "default_value")
+	}
+	if !reflect.DeepEqual(a.Condition, b.Condition) {
+		props = append(props, "condition")
+	}
+
+	return props
+}
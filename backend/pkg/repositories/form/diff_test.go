@@ -0,0 +1,60 @@
+package form_repo_test
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+	"slices"
+	"testing"
+)
+
+func TestDiffFormData(t *testing.T) {
+	from := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Title: "About you", Fields: []form_repo.Field{
+				{UUID: "f1", Label: "Name"},
+				{UUID: "f2", Label: "Email"},
+				{UUID: "f3", Label: "Phone"},
+			}},
+			{UUID: "s2", Title: "Extra"},
+		},
+	}
+
+	to := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s0", Title: "Welcome"},
+			{UUID: "s1", Title: "About you", Fields: []form_repo.Field{
+				{UUID: "f1", Label: "Full name", Required: true},
+				{UUID: "f2", Label: "Email"},
+				{UUID: "f4", Label: "Company"},
+			}},
+		},
+	}
+
+	diff := form_repo.DiffFormData(from, to)
+
+	steps := map[string]string{}
+	for _, s := range diff.Steps {
+		steps[s.UUID] = s.Change
+	}
+
+	// s1 only shifted because s0 was inserted before it so it is not a change
+	if len(steps) != 2 || steps["s0"] != form_repo.ChangeAdded || steps["s2"] != form_repo.ChangeRemoved {
+		t.Errorf("unexpected step changes %+v", diff.Steps)
+	}
+
+	fields := map[string]form_repo.FieldChange{}
+	for _, f := range diff.Fields {
+		fields[f.UUID] = f
+	}
+
+	if len(fields) != 3 {
+		t.Fatalf("expected 3 field changes, got %+v", diff.Fields)
+	}
+
+	if fields["f3"].Change != form_repo.ChangeRemoved || fields["f4"].Change != form_repo.ChangeAdded {
+		t.Errorf("unexpected field changes %+v", diff.Fields)
+	}
+
+	if !slices.Equal(fields["f1"].Properties, []string{"label", "required"}) {
+		t.Errorf("expected label and required to change, got %v", fields["f1"].Properties)
+	}
+}
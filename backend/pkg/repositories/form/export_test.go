@@ -0,0 +1,11 @@
+package form_repo
+
+// the listing query builders, exported for the tests in form_repo_test
+var (
+	ListingFilters    = listingFilters
+	BuildListingQuery = buildListingQuery
+)
+
+func (q *ListingQuery) Normalize() error {
+	return q.normalize()
+}
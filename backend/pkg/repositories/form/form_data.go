@@ -1,8 +1,11 @@
 package form_repo
 
+import theme_repo "formaura/pkg/repositories/themes"
+
 type FormData struct {
-	Steps []Step `json:"steps"`
-	Hero  *Hero  `json:"hero,omitempty"`
+	Steps []Step            `json:"steps"`
+	Hero  *Hero             `json:"hero,omitempty"`
+	Theme *theme_repo.Model `json:"theme,omitempty"`
 }
 
 type Step struct {
@@ -34,7 +37,7 @@ type Condition struct {
 type CondRule struct {
 	Type     string      `json:"type"`     // "field" or "step"
 	UUID     string      `json:"uuid"`     // UUID of the field or step to check
-	Operator string      `json:"operator"` // "equals", "notEquals", "contains", "greaterThan", "lessThan", "isEmpty", "isNotEmpty"
+	Operator string      `json:"operator"` // "equals", "notEquals", "contains", "greaterThan", "lessThan", "isEmpty", "isNotEmpty", "isCompleted"
 	Value    interface{} `json:"value"`    // Value to compare against
 }
 
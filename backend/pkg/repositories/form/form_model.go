@@ -2,22 +2,28 @@ package form_repo
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
 type FormModel struct {
-	ID              int             `json:"-" db:"id"`
-	UUID            string          `json:"uuid" db:"uuid"`
-	UserID          int             `json:"-" db:"user_id"`
-	Name            string          `json:"name" db:"name"`
-	Description     *string         `json:"description" db:"description"`
-	FormData        json.RawMessage `json:"form_data,omitempty" db:"form_data"` // Use json.RawMessage for JSONB
-	Status          string          `json:"status" db:"status"`
-	Views           int             `json:"views" db:"views"`
-	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
-	Affiliates      json.RawMessage `json:"affiliates,omitempty" db:"affiliates"`
-	SubmissionCount int             `json:"submission_count" db:"submission_count"`
+	ID                 int             `json:"-" db:"id"`
+	UUID               string          `json:"uuid" db:"uuid"`
+	UserID             int             `json:"-" db:"user_id"`
+	Name               string          `json:"name" db:"name"`
+	Description        *string         `json:"description" db:"description"`
+	FormData           json.RawMessage `json:"form_data,omitempty" db:"form_data"` // Use json.RawMessage for JSONB
+	Status             string          `json:"status" db:"status"`
+	Views              int             `json:"views" db:"views"`
+	UniqueViews        int             `json:"unique_views" db:"unique_views"`
+	PublishedVersionID *int            `json:"-" db:"published_version_id"`
+	Revision           int             `json:"revision" db:"revision"`
+	DeletedAt          *time.Time      `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedAt          time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at" db:"updated_at"`
+	Affiliates         json.RawMessage `json:"affiliates,omitempty" db:"affiliates"`
+	SubmissionCount    int             `json:"submission_count" db:"submission_count"`
+	SearchLanguage     string          `json:"search_language" db:"search_language"`
 }
 
 const (
@@ -28,11 +34,32 @@ const (
 
 var ValidStatuses = []string{StatusInactive, StatusDraft, StatusActive}
 
+// SearchLanguages are the Postgres text search configurations submissions can
+// be indexed with, simple does no stemming and suits mixed language forms
+var SearchLanguages = []string{
+	"simple", "arabic", "danish", "dutch", "english", "finnish", "french",
+	"german", "greek", "hungarian", "indonesian", "irish", "italian",
+	"lithuanian", "nepali", "norwegian", "portuguese", "romanian", "russian",
+	"spanish", "swedish", "tamil", "turkish",
+}
+
 // Helper method to unmarshal FormData into a specific struct
 func (m *FormModel) UnmarshalFormData(v interface{}) error {
 	return json.Unmarshal(m.FormData, v)
 }
 
+// ETag identifies the draft revision for If-Match checks on updates
+func (m *FormModel) ETag() string {
+	return fmt.Sprintf(`"%d"`, m.Revision)
+}
+
+// ApplyVersion swaps the draft content for a published version's, used on the public routes
+func (m *FormModel) ApplyVersion(v *VersionModel) {
+	m.Name = v.Name
+	m.Description = v.Description
+	m.FormData = v.FormData
+}
+
 // Helper method to unmarshal Affiliates into AffiliateInfo slice
 func (m *FormModel) GetAffiliates() ([]AffiliateInfo, error) {
 	var affiliates []AffiliateInfo
@@ -41,7 +68,8 @@ func (m *FormModel) GetAffiliates() ([]AffiliateInfo, error) {
 }
 
 type AffiliateInfo struct {
-	UUID      string `json:"uuid"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
+	UUID            string `json:"uuid"`
+	FirstName       string `json:"first_name"`
+	LastName        string `json:"last_name"`
+	SubmissionCount int    `json:"submission_count"`
 }
@@ -0,0 +1,181 @@
+package form_repo
+
+import (
+	"fmt"
+	"formaura/pkg/keyset"
+	"strings"
+	"time"
+)
+
+// Listing sort keys, every sort is tie broken on uuid which is a UUIDv7 and so
+// also orders by creation
+const (
+	SortName        = "name"
+	SortCreated     = "created"
+	SortUpdated     = "updated"
+	SortViews       = "views"
+	SortSubmissions = "submissions"
+)
+
+var ValidSorts = []string{SortName, SortCreated, SortUpdated, SortViews, SortSubmissions}
+
+// views is nullable, NULL sorts as 0 so it can be compared in a cursor
+var sortColumns = map[string]string{
+	SortName:        "f.name",
+	SortCreated:     "f.created_at",
+	SortUpdated:     "f.updated_at",
+	SortViews:       "COALESCE(f.views, 0)",
+	SortSubmissions: "f.submission_count",
+}
+
+const (
+	DefaultListingLimit = 50
+	MaxListingLimit     = 100
+)
+
+var ErrInvalidCursor = keyset.ErrInvalidCursor
+
+// ListingQuery filters, sorts and pages a user's forms. Empty fields do not filter.
+type ListingQuery struct {
+	Status        string
+	Search        string
+	CreatedFrom   *time.Time // inclusive
+	CreatedBefore *time.Time // exclusive
+	Sort          string
+	Asc           bool
+	Cursor        string
+	Limit         int
+}
+
+type Listing struct {
+	Forms      []*FormModel `json:"forms"`
+	Total      int          `json:"total"`
+	NextCursor *string      `json:"next_cursor"`
+}
+
+func encodeCursor(q *ListingQuery, last *FormModel) string {
+	c := keyset.Cursor{Sort: q.Sort, Asc: q.Asc, UUID: last.UUID}
+
+	switch q.Sort {
+	case SortName:
+		c.Value = last.Name
+	case SortCreated:
+		c.Value = keyset.FormatTime(last.CreatedAt)
+	case SortUpdated:
+		c.Value = keyset.FormatTime(last.UpdatedAt)
+	case SortViews:
+		c.Value = keyset.FormatInt(last.Views)
+	case SortSubmissions:
+		c.Value = keyset.FormatInt(last.SubmissionCount)
+	}
+
+	return c.Encode()
+}
+
+// decodeCursor returns the sort value and uuid to continue after
+func decodeCursor(q *ListingQuery) (any, string, error) {
+	c, err := keyset.Decode(q.Cursor, q.Sort, q.Asc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var value any
+
+	switch q.Sort {
+	case SortCreated, SortUpdated:
+		value, err = c.Time()
+	case SortViews, SortSubmissions:
+		value, err = c.Int()
+	default:
+		value = c.Value
+	}
+
+	return value, c.UUID, err
+}
+
+// normalize fills in defaults and checks the sort
+func (q *ListingQuery) normalize() error {
+	if q.Sort == "" {
+		q.Sort = SortCreated
+	}
+	if _, ok := sortColumns[q.Sort]; !ok {
+		return fmt.Errorf("form: unknown sort %q", q.Sort)
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultListingLimit
+	}
+	if q.Limit > MaxListingLimit {
+		q.Limit = MaxListingLimit
+	}
+	return nil
+}
+
+// escapeLike escapes the ILIKE wildcards in a user supplied search
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// listingFilters builds the WHERE clause shared by the page and the total count
+func listingFilters(userId int, q *ListingQuery) (string, []any) {
+	where := []string{"f.user_id = $1", "f.deleted_at IS NULL"}
+	args := []any{userId}
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.Status != "" {
+		where = append(where, "f.status = "+arg(q.Status))
+	}
+	if search := strings.TrimSpace(q.Search); search != "" {
+		where = append(where, "f.name ILIKE '%' || "+arg(escapeLike(search))+" || '%'")
+	}
+	if q.CreatedFrom != nil {
+		where = append(where, "f.created_at >= "+arg(*q.CreatedFrom))
+	}
+	if q.CreatedBefore != nil {
+		where = append(where, "f.created_at < "+arg(*q.CreatedBefore))
+	}
+
+	return strings.Join(where, " AND "), args
+}
+
+// buildListingQuery returns the page query, it fetches one row more than the
+// limit so the caller can tell whether there is a next page
+func buildListingQuery(userId int, q *ListingQuery) (string, []any, error) {
+	where, args := listingFilters(userId, q)
+
+	column := sortColumns[q.Sort]
+	dir, cmp := keyset.Direction(q.Asc)
+
+	if q.Cursor != "" {
+		value, uuid, err := decodeCursor(q)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, value, uuid)
+		where += fmt.Sprintf(" AND (%s, f.uuid) %s ($%d, $%d)", column, cmp, len(args)-1, len(args))
+	}
+
+	args = append(args, q.Limit+1)
+
+	query := fmt.Sprintf(`
+	SELECT
+	f.uuid,
+	f.name,
+	f.description,
+	f.status,
+	COALESCE(f.views, 0) AS views,
+	f.unique_views,
+	f.created_at,
+	f.updated_at,
+	f.submission_count,
+	%s
+	FROM forms f
+	WHERE %s
+	ORDER BY %s %s, f.uuid %s
+	LIMIT $%d`, affiliatesColumn, where, column, dir, dir, len(args))
+
+	return query, args, nil
+}
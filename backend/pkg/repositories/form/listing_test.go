@@ -0,0 +1,118 @@
+package form_repo_test
+
+import (
+	"errors"
+	"formaura/pkg/keyset"
+	form_repo "formaura/pkg/repositories/form"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListingFilters(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		query form_repo.ListingQuery
+		where string
+		args  []any
+	}{
+		"none": {
+			form_repo.ListingQuery{},
+			"f.user_id = $1 AND f.deleted_at IS NULL",
+			[]any{7},
+		},
+		"status and dates": {
+			form_repo.ListingQuery{Status: form_repo.StatusActive, CreatedFrom: &from, CreatedBefore: &before},
+			"f.user_id = $1 AND f.deleted_at IS NULL AND f.status = $2 AND f.created_at >= $3 AND f.created_at < $4",
+			[]any{7, form_repo.StatusActive, from, before},
+		},
+		// wildcards in the search are matched literally
+		"search": {
+			form_repo.ListingQuery{Search: ` 100%_off\ `},
+			"f.user_id = $1 AND f.deleted_at IS NULL AND f.name ILIKE '%' || $2 || '%'",
+			[]any{7, `100\%\_off\\`},
+		},
+	}
+
+	for name, c := range cases {
+		where, args := form_repo.ListingFilters(7, &c.query)
+
+		if where != c.where {
+			t.Errorf("%s: expected where\n%s\ngot\n%s", name, c.where, where)
+		}
+
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%s: expected args %v, got %v", name, c.args, args)
+		}
+	}
+}
+
+func TestListingSorts(t *testing.T) {
+	cases := map[string]struct {
+		sort      string
+		asc       bool
+		value     string
+		predicate string
+		order     string
+		bound     any
+	}{
+		"newest first": {
+			order: "ORDER BY f.created_at DESC, f.uuid DESC",
+		},
+		// forms without views sort as 0 rather than dropping out of the page
+		"most viewed": {
+			sort:      form_repo.SortViews,
+			value:     "10",
+			predicate: "AND (COALESCE(f.views, 0), f.uuid) < ($2, $3)",
+			order:     "ORDER BY COALESCE(f.views, 0) DESC, f.uuid DESC",
+			bound:     int64(10),
+		},
+		"name ascending": {
+			sort:      form_repo.SortName,
+			asc:       true,
+			value:     "Leads",
+			predicate: "AND (f.name, f.uuid) > ($2, $3)",
+			order:     "ORDER BY f.name ASC, f.uuid ASC",
+			bound:     "Leads",
+		},
+	}
+
+	for name, c := range cases {
+		q := &form_repo.ListingQuery{Sort: c.sort, Asc: c.asc}
+		if err := q.Normalize(); err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+
+		if c.predicate != "" {
+			q.Cursor = (&keyset.Cursor{Sort: q.Sort, Asc: q.Asc, Value: c.value, UUID: "last"}).Encode()
+		}
+
+		query, args, err := form_repo.BuildListingQuery(7, q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+
+		if c.predicate != "" && (!strings.Contains(query, c.predicate) || args[1] != c.bound) {
+			t.Errorf("%s: expected %q bound to %v in\n%s\n%v", name, c.predicate, c.bound, query, args)
+		}
+
+		if !strings.Contains(query, c.order) {
+			t.Errorf("%s: expected %q in\n%s", name, c.order, query)
+		}
+	}
+
+	// a count sort only continues after a whole number
+	q := &form_repo.ListingQuery{Sort: form_repo.SortViews}
+	q.Cursor = (&keyset.Cursor{Sort: form_repo.SortViews, Value: "ten", UUID: "last"}).Encode()
+	if _, _, err := form_repo.BuildListingQuery(7, q); !errors.Is(err, form_repo.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	q = &form_repo.ListingQuery{Sort: "owner"}
+	if err := q.Normalize(); err == nil {
+		t.Error("expected an unknown sort to be rejected")
+	}
+}
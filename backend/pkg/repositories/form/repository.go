@@ -3,24 +3,65 @@ package form_repo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"formaura/pkg/db"
 	"time"
 
 	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// ErrStaleRevision is returned by draft updates when the expected revision no
+// longer matches, ie. someone else saved the form first
+var ErrStaleRevision = errors.New("form revision is stale")
+
 type Repository interface {
 	Create(ctx context.Context, userId int, name string, description *string, formData FormData) (*FormModel, error)
+	Duplicate(ctx context.Context, sourceId int, userId int, name string, formData FormData, withAffiliates bool) (*FormModel, error)
 	GetByUUID(ctx context.Context, uuid string) (*FormModel, error)
 	GetByID(ctx context.Context, id int) (*FormModel, error)
 	GetBasicListingByUserID(ctx context.Context, id int) ([]*FormModel, error)
-	GetDetailedListingByUserID(ctx context.Context, id int) ([]*FormModel, error)
-	UpdateFormMeta(ctx context.Context, id int, name, description string, status string) (*FormModel, error)
-	IncrementViews(ctx context.Context, uuid string) error
+	GetDetailedListingByUserID(ctx context.Context, id int, q ListingQuery) (*Listing, error)
+	UpdateFormMeta(ctx context.Context, id int, revision int, name, description string, status string) (*FormModel, error)
+	UpdateFormData(ctx context.Context, id int, revision int, formData FormData) (*FormModel, error)
+	UpdateSearchLanguage(ctx context.Context, id int, language string) (*FormModel, error)
+	PatchFormData(ctx context.Context, id int, revision int, apply func(current json.RawMessage) (*FormData, error)) (*FormModel, error)
+	AddViews(ctx context.Context, counts map[ViewKey]int, visitors []Visitor) error
+	GetDailyStats(ctx context.Context, formId int, from, before time.Time) ([]*DailyStatsModel, error)
+	ReconcileCounters(ctx context.Context) (int64, error)
 	Delete(ctx context.Context, uuid string) error
+	SoftDelete(ctx context.Context, id int) error
+	GetTrashedByUUID(ctx context.Context, uuid string) (*FormModel, error)
+	GetTrashListingByUserID(ctx context.Context, id int) ([]*FormModel, error)
+	RestoreFromTrash(ctx context.Context, id int) (*FormModel, error)
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	GetRevisionsByFormID(ctx context.Context, formId int) ([]*RevisionModel, error)
+	GetRevision(ctx context.Context, formId int, revision int) (*RevisionModel, error)
+	RestoreRevision(ctx context.Context, formId int, expectedRevision int, revision int) (*FormModel, error)
+	Publish(ctx context.Context, formId int) (*VersionModel, error)
+	GetVersionByID(ctx context.Context, id int) (*VersionModel, error)
+	GetVersionsByFormID(ctx context.Context, formId int) ([]*VersionModel, error)
+	GetVersionSnapshotsByFormID(ctx context.Context, formId int) ([]*VersionModel, error)
 }
 
+// affiliatesColumn selects a form's affiliates with their submission counters
+// as a json array, it expects the form to be aliased as f
+const affiliatesColumn = `COALESCE(
+		(SELECT jsonb_agg(
+			jsonb_build_object(
+				'uuid', a.uuid,
+				'first_name', a.first_name,
+				'last_name', a.last_name,
+				'submission_count', fa.submission_count
+			) ORDER BY a.id)
+		FROM form_affiliates fa
+		JOIN affiliates a ON fa.affiliate_id = a.id
+		WHERE fa.form_id = f.id),
+		'[]'::jsonb
+	) as affiliates`
+
 type FormRepository struct {
 	db *pgxpool.Pool
 }
@@ -44,14 +85,84 @@ func (r *FormRepository) Create(ctx context.Context, user_id int, name string, d
 		RETURNING *
 	`
 
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.Create begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var form FormModel
 
-	err = pgxscan.Get(ctx, r.db, &form, query, user_id, name, description, formDataJSON, now, now)
+	err = pgxscan.Get(ctx, tx, &form, query, user_id, name, description, formDataJSON, now, now)
 
 	if err != nil {
 		return nil, fmt.Errorf("form.Create query: %w", err)
 	}
 
+	if err := insertRevision(ctx, tx, form.ID); err != nil {
+		return nil, fmt.Errorf("form.Create: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.Create commit: %w", err)
+	}
+
+	return &form, nil
+}
+
+// Duplicate creates a new draft from an existing form with the given name and
+// form data, optionally attaching the same affiliates as the source
+func (r *FormRepository) Duplicate(ctx context.Context, sourceId int, userId int, name string, formData FormData, withAffiliates bool) (*FormModel, error) {
+	now := time.Now()
+
+	formDataJSON, err := json.Marshal(formData)
+	if err != nil {
+		return nil, fmt.Errorf("form.Duplicate marshal: %w", err)
+	}
+
+	query := `
+		INSERT INTO forms (user_id, name, description, form_data, created_at, updated_at)
+		SELECT $1, $2, description, $3, $4, $4
+		FROM forms
+		WHERE id = $5
+		RETURNING *
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.Duplicate begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var form FormModel
+
+	err = pgxscan.Get(ctx, tx, &form, query, userId, name, formDataJSON, now, sourceId)
+	if err != nil {
+		return nil, fmt.Errorf("form.Duplicate query: %w", err)
+	}
+
+	if withAffiliates {
+		copy_affiliates := `
+			INSERT INTO form_affiliates (form_id, affiliate_id, added_at)
+			SELECT $1, affiliate_id, $2
+			FROM form_affiliates
+			WHERE form_id = $3
+		`
+
+		_, err = tx.Exec(ctx, copy_affiliates, form.ID, now, sourceId)
+		if err != nil {
+			return nil, fmt.Errorf("form.Duplicate affiliates: %w", err)
+		}
+	}
+
+	if err := insertRevision(ctx, tx, form.ID); err != nil {
+		return nil, fmt.Errorf("form.Duplicate: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.Duplicate commit: %w", err)
+	}
+
 	return &form, nil
 }
 
@@ -59,25 +170,9 @@ func (r *FormRepository) GetByUUID(ctx context.Context, uuid string) (*FormModel
 	var form FormModel
 
 	query := `
-	SELECT
-		f.*,
-		COALESCE(
-			jsonb_agg(
-				jsonb_build_object(
-					'uuid', a.uuid,
-					'first_name', a.first_name,
-					'last_name', a.last_name
-				)
-			) FILTER (WHERE a.uuid IS NOT NULL),
-			'[]'::jsonb
-		) as affiliates,
-		COUNT(DISTINCT fs.id) as submission_count
+	SELECT f.*, ` + affiliatesColumn + `
 	FROM forms f
-	LEFT JOIN form_affiliates fa ON f.id = fa.form_id
-	LEFT JOIN affiliates a ON fa.affiliate_id = a.id
-	LEFT JOIN form_submissions fs ON f.id = fs.form_id
-	WHERE f.uuid=$1
-	GROUP BY f.id`
+	WHERE f.uuid=$1 AND f.deleted_at IS NULL`
 
 	err := pgxscan.Get(ctx, r.db, &form, query, uuid)
 	if err != nil {
@@ -91,25 +186,9 @@ func (r *FormRepository) GetByID(ctx context.Context, id int) (*FormModel, error
 	var form FormModel
 
 	query := `
-	SELECT
-		f.*,
-		COALESCE(
-			jsonb_agg(
-				jsonb_build_object(
-					'uuid', a.uuid,
-					'first_name', a.first_name,
-					'last_name', a.last_name
-				)
-			) FILTER (WHERE a.uuid IS NOT NULL),
-			'[]'::jsonb
-		) as affiliates,
-		COUNT(DISTINCT fs.id) as submission_count
+	SELECT f.*, ` + affiliatesColumn + `
 	FROM forms f
-	LEFT JOIN form_affiliates fa ON f.id = fa.form_id
-	LEFT JOIN affiliates a ON fa.affiliate_id = a.id
-	LEFT JOIN form_submissions fs ON f.id = fs.form_id
-	WHERE f.id=$1
-	GROUP BY f.id`
+	WHERE f.id=$1 AND f.deleted_at IS NULL`
 
 	err := pgxscan.Get(ctx, r.db, &form, query, id)
 	if err != nil {
@@ -125,7 +204,7 @@ func (r *FormRepository) GetBasicListingByUserID(ctx context.Context, id int) ([
 	query := `
 	SELECT uuid, name, description, status, views, created_at, updated_at
 	FROM forms
-	WHERE user_id = $1
+	WHERE user_id = $1 AND deleted_at IS NULL
 	ORDER BY created_at DESC`
 
 	err := pgxscan.Select(ctx, r.db, &forms, query, id)
@@ -136,108 +215,379 @@ func (r *FormRepository) GetBasicListingByUserID(ctx context.Context, id int) ([
 	return forms, nil
 }
 
-func (r *FormRepository) GetDetailedListingByUserID(ctx context.Context, id int) ([]*FormModel, error) {
-	forms := []*FormModel{}
+// GetDetailedListingByUserID returns one page of a user's forms along with the
+// total matching the filters and a cursor for the next page
+func (r *FormRepository) GetDetailedListingByUserID(ctx context.Context, id int, q ListingQuery) (*Listing, error) {
+	if err := q.normalize(); err != nil {
+		return nil, err
+	}
 
-	query := `
-	SELECT
-	f.uuid,
-	f.name,
-	f.description,
-	f.status,
-	f.views,
-	f.created_at,
-	f.updated_at,
-	COALESCE(
-		jsonb_agg(
-			jsonb_build_object(
-				'uuid', a.uuid,
-				'first_name', a.first_name,
-				'last_name', a.last_name
-				)
-			) FILTER (WHERE a.uuid IS NOT NULL),
-			'[]'::jsonb
-		) as affiliates,
-		COUNT(DISTINCT fs.id) as submission_count
-	FROM forms f
-	LEFT JOIN form_affiliates fa ON f.id = fa.form_id
-	LEFT JOIN affiliates a ON fa.affiliate_id = a.id
-	LEFT JOIN form_submissions fs ON f.id = fs.form_id
-	WHERE f.user_id = $1
-	GROUP BY f.id, f.uuid, f.name, f.description, f.status, f.views, f.created_at, f.updated_at
-	ORDER BY f.created_at DESC`
+	query, args, err := buildListingQuery(id, &q)
+	if err != nil {
+		return nil, err
+	}
 
-	err := pgxscan.Select(ctx, r.db, &forms, query, id)
+	listing := &Listing{Forms: []*FormModel{}}
+
+	err = pgxscan.Select(ctx, r.db, &listing.Forms, query, args...)
 	if err != nil {
-		fmt.Println(err)
 		return nil, fmt.Errorf("form.GetDetailedListingByUserID query: %w", err)
 	}
 
-	return forms, nil
+	where, countArgs := listingFilters(id, &q)
+
+	err = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM forms f WHERE `+where, countArgs...).Scan(&listing.Total)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetDetailedListingByUserID count: %w", err)
+	}
+
+	if len(listing.Forms) > q.Limit {
+		listing.Forms = listing.Forms[:q.Limit]
+
+		cursor := encodeCursor(&q, listing.Forms[q.Limit-1])
+		listing.NextCursor = &cursor
+	}
+
+	return listing, nil
 }
-func (r *FormRepository) UpdateFormMeta(ctx context.Context, id int, name, description string, status string) (*FormModel, error) {
+
+func (r *FormRepository) UpdateFormMeta(ctx context.Context, id int, revision int, name, description string, status string) (*FormModel, error) {
 	now := time.Now()
-	fmt.Println(description)
 
 	var query = `
 			UPDATE forms
-			SET name=$1, description=$2, status=$3, updated_at=$4
-			WHERE id=$5
+			SET name=$1, description=$2, status=$3, updated_at=$4, revision=revision + 1
+			WHERE id=$5 AND revision=$6
 			RETURNING *
 		`
 
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.UpdateFormMeta begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var form FormModel
 
-	err := pgxscan.Get(ctx, r.db, &form, query, name, description, status, now, id)
+	err = pgxscan.Get(ctx, tx, &form, query, name, description, status, now, id, revision)
 
 	if err != nil {
+		if db.IsNoRowsError(err) {
+			return nil, ErrStaleRevision
+		}
 		return nil, fmt.Errorf("form.Update query: %w", err)
 	}
 
+	if err := insertRevision(ctx, tx, form.ID); err != nil {
+		return nil, fmt.Errorf("form.UpdateFormMeta: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.UpdateFormMeta commit: %w", err)
+	}
+
+	return &form, nil
+}
+
+// UpdateSearchLanguage changes the text search configuration of a form and
+// reindexes its submissions with it. It is a setting so no revision is made.
+func (r *FormRepository) UpdateSearchLanguage(ctx context.Context, id int, language string) (*FormModel, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.UpdateSearchLanguage begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var form FormModel
+
+	query := `UPDATE forms SET search_language=$1, updated_at=$2 WHERE id=$3 RETURNING *`
+
+	err = pgxscan.Get(ctx, tx, &form, query, language, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("form.UpdateSearchLanguage query: %w", err)
+	}
+
+	// rewriting search_language regenerates search_vector
+	reindex := `
+		UPDATE form_submissions
+		SET search_language=$1::regconfig
+		WHERE form_id=$2 AND search_language <> $1::regconfig`
+
+	if _, err := tx.Exec(ctx, reindex, language, id); err != nil {
+		return nil, fmt.Errorf("form.UpdateSearchLanguage reindex: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.UpdateSearchLanguage commit: %w", err)
+	}
+
 	return &form, nil
 }
 
-func (r *FormRepository) UpdateFormData(ctx context.Context, uuid, name, description string, formData FormData) (*FormModel, error) {
+func (r *FormRepository) UpdateFormData(ctx context.Context, id int, revision int, formData FormData) (*FormModel, error) {
 	now := time.Now()
 
 	// Marshal formData to JSON
 	formDataJSON, err := json.Marshal(formData)
 	if err != nil {
-		return nil, fmt.Errorf("form.Update marshal: %w", err)
+		return nil, fmt.Errorf("form.UpdateFormData marshal: %w", err)
 	}
 
 	query := `
 		UPDATE forms 
-		SET name=$1, description=$2, form_data=$3, updated_at=$4 
-		WHERE uuid=$5
+		SET form_data=$1, updated_at=$2, revision=revision + 1 
+		WHERE id=$3 AND revision=$4
 		RETURNING *
 	`
 
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.UpdateFormData begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var form FormModel
 
-	err = pgxscan.Get(ctx, r.db, &form, query, name, description, formDataJSON, now, uuid)
+	err = pgxscan.Get(ctx, tx, &form, query, formDataJSON, now, id, revision)
 	if err != nil {
-		return nil, fmt.Errorf("form.Update query: %w", err)
+		if db.IsNoRowsError(err) {
+			return nil, ErrStaleRevision
+		}
+		return nil, fmt.Errorf("form.UpdateFormData query: %w", err)
+	}
+
+	if err := insertRevision(ctx, tx, form.ID); err != nil {
+		return nil, fmt.Errorf("form.UpdateFormData: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.UpdateFormData commit: %w", err)
 	}
 
 	return &form, nil
 }
 
-func (r *FormRepository) IncrementViews(ctx context.Context, uuid string) error {
+// PatchFormData locks the form, hands the current form_data to apply and saves
+// whatever it returns in the same transaction, errors from apply are returned
+// unwrapped so callers can match on them
+func (r *FormRepository) PatchFormData(ctx context.Context, id int, revision int, apply func(current json.RawMessage) (*FormData, error)) (*FormModel, error) {
+	now := time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.PatchFormData begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current json.RawMessage
+
+	err = tx.QueryRow(ctx, `SELECT form_data FROM forms WHERE id=$1 AND revision=$2 FOR UPDATE`, id, revision).Scan(&current)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			return nil, ErrStaleRevision
+		}
+		return nil, fmt.Errorf("form.PatchFormData select: %w", err)
+	}
+
+	formData, err := apply(current)
+	if err != nil {
+		return nil, err
+	}
+
+	formDataJSON, err := json.Marshal(formData)
+	if err != nil {
+		return nil, fmt.Errorf("form.PatchFormData marshal: %w", err)
+	}
+
 	query := `
-	    UPDATE forms 
-	    SET views = views + 1 
-	    WHERE uuid=$1
+		UPDATE forms
+		SET form_data=$1, updated_at=$2, revision=revision + 1
+		WHERE id=$3
+		RETURNING *
 	`
 
-	_, err := r.db.Exec(ctx, query, uuid)
+	var form FormModel
+
+	err = pgxscan.Get(ctx, tx, &form, query, formDataJSON, now, id)
 	if err != nil {
-		return fmt.Errorf("form.IncrementViews: %w", err)
+		return nil, fmt.Errorf("form.PatchFormData query: %w", err)
+	}
+
+	if err := insertRevision(ctx, tx, form.ID); err != nil {
+		return nil, fmt.Errorf("form.PatchFormData: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.PatchFormData commit: %w", err)
+	}
+
+	return &form, nil
+}
+
+// AddViews adds a batch of buffered view counts and counts each visitor not
+// already seen that day as a unique view, both on the form's lifetime totals
+// and in form_daily_stats. Forms purged since the views were buffered are skipped.
+func (r *FormRepository) AddViews(ctx context.Context, counts map[ViewKey]int, visitors []Visitor) error {
+	viewFormIds := make([]int32, 0, len(counts))
+	viewDays := make([]time.Time, 0, len(counts))
+	viewCounts := make([]int32, 0, len(counts))
+
+	for key, n := range counts {
+		viewFormIds = append(viewFormIds, int32(key.FormID))
+		viewDays = append(viewDays, key.Day)
+		viewCounts = append(viewCounts, int32(n))
+	}
+
+	visitorFormIds := make([]int32, 0, len(visitors))
+	visitorDays := make([]time.Time, 0, len(visitors))
+	visitorHashes := make([]string, 0, len(visitors))
+
+	for _, v := range visitors {
+		visitorFormIds = append(visitorFormIds, int32(v.FormID))
+		visitorDays = append(visitorDays, v.Day)
+		visitorHashes = append(visitorHashes, v.Hash)
+	}
+
+	add_views := `
+		WITH v AS (
+			SELECT v.*
+			FROM unnest($1::int[], $2::date[], $3::int[]) AS v(form_id, day, count)
+			JOIN forms f ON f.id = v.form_id
+		), totals AS (
+			UPDATE forms f
+			SET views = COALESCE(f.views, 0) + t.count
+			FROM (SELECT form_id, SUM(count) AS count FROM v GROUP BY form_id) t
+			WHERE f.id = t.form_id
+		)
+		INSERT INTO form_daily_stats (form_id, day, views)
+		SELECT form_id, day, count FROM v
+		ON CONFLICT (form_id, day) DO UPDATE SET views = form_daily_stats.views + EXCLUDED.views
+	`
+
+	add_unique_views := `
+		WITH seen AS (
+			INSERT INTO form_visitors (form_id, day, visitor_hash)
+			SELECT v.*
+			FROM unnest($1::int[], $2::date[], $3::text[]) AS v(form_id, day, visitor_hash)
+			JOIN forms f ON f.id = v.form_id
+			ON CONFLICT DO NOTHING
+			RETURNING form_id, day
+		), per_day AS (
+			SELECT form_id, day, COUNT(*) AS count FROM seen GROUP BY form_id, day
+		), totals AS (
+			UPDATE forms f
+			SET unique_views = f.unique_views + t.count
+			FROM (SELECT form_id, SUM(count) AS count FROM per_day GROUP BY form_id) t
+			WHERE f.id = t.form_id
+		)
+		INSERT INTO form_daily_stats (form_id, day, unique_views)
+		SELECT form_id, day, count FROM per_day
+		ON CONFLICT (form_id, day) DO UPDATE SET unique_views = form_daily_stats.unique_views + EXCLUDED.unique_views
+	`
+
+	// earlier days' salts were discarded by views.Counter so their hashes can
+	// never match again, yesterday is kept for views buffered either side of
+	// midnight
+	clear_old_visitors := `DELETE FROM form_visitors WHERE day < $1::date - 1`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("form.AddViews begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if len(counts) > 0 {
+		if _, err := tx.Exec(ctx, add_views, viewFormIds, viewDays, viewCounts); err != nil {
+			return fmt.Errorf("form.AddViews views: %w", err)
+		}
+	}
+
+	if len(visitors) > 0 {
+		if _, err := tx.Exec(ctx, add_unique_views, visitorFormIds, visitorDays, visitorHashes); err != nil {
+			return fmt.Errorf("form.AddViews unique views: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, clear_old_visitors, time.Now().UTC()); err != nil {
+		return fmt.Errorf("form.AddViews clear visitors: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("form.AddViews commit: %w", err)
 	}
 
 	return nil
 }
 
+// GetDailyStats returns the days in [from, before) that had any activity,
+// oldest first
+func (r *FormRepository) GetDailyStats(ctx context.Context, formId int, from, before time.Time) ([]*DailyStatsModel, error) {
+	stats := []*DailyStatsModel{}
+
+	query := `
+	SELECT *
+	FROM form_daily_stats
+	WHERE form_id = $1 AND day >= $2::date AND day < $3::date
+	ORDER BY day`
+
+	err := pgxscan.Select(ctx, r.db, &stats, query, formId, from, before)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetDailyStats query: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ReconcileCounters recomputes the submission counters that the
+// form_submissions trigger maintains and returns how many rows were off
+func (r *FormRepository) ReconcileCounters(ctx context.Context) (int64, error) {
+	reconcile_forms := `
+		UPDATE forms f
+		SET submission_count = c.count
+		FROM (
+			SELECT f.id, COUNT(fs.id) AS count
+			FROM forms f
+			LEFT JOIN form_submissions fs ON fs.form_id = f.id
+			GROUP BY f.id
+		) c
+		WHERE c.id = f.id AND c.count <> f.submission_count`
+
+	reconcile_form_affiliates := `
+		UPDATE form_affiliates fa
+		SET submission_count = c.count
+		FROM (
+			SELECT fa.form_id, fa.affiliate_id, COUNT(fs.id) AS count
+			FROM form_affiliates fa
+			LEFT JOIN form_submissions fs ON fs.form_id = fa.form_id AND fs.affiliate_id = fa.affiliate_id
+			GROUP BY fa.form_id, fa.affiliate_id
+		) c
+		WHERE c.form_id = fa.form_id AND c.affiliate_id = fa.affiliate_id AND c.count <> fa.submission_count`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("form.ReconcileCounters begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	formsTag, err := tx.Exec(ctx, reconcile_forms)
+	if err != nil {
+		return 0, fmt.Errorf("form.ReconcileCounters forms: %w", err)
+	}
+
+	affiliatesTag, err := tx.Exec(ctx, reconcile_form_affiliates)
+	if err != nil {
+		return 0, fmt.Errorf("form.ReconcileCounters form_affiliates: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("form.ReconcileCounters commit: %w", err)
+	}
+
+	return formsTag.RowsAffected() + affiliatesTag.RowsAffected(), nil
+}
+
+// Delete permanently removes a form, this cascades to its submissions so
+// handlers should go through SoftDelete and leave purging to the trash sweeper
 func (r *FormRepository) Delete(ctx context.Context, uuid string) error {
 	query := `DELETE FROM forms WHERE uuid=$1`
 
@@ -248,3 +598,268 @@ func (r *FormRepository) Delete(ctx context.Context, uuid string) error {
 
 	return nil
 }
+
+func (r *FormRepository) SoftDelete(ctx context.Context, id int) error {
+	query := `UPDATE forms SET deleted_at=$1 WHERE id=$2 AND deleted_at IS NULL`
+
+	_, err := r.db.Exec(ctx, query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("form.SoftDelete: %w", err)
+	}
+
+	return nil
+}
+
+func (r *FormRepository) GetTrashedByUUID(ctx context.Context, uuid string) (*FormModel, error) {
+	var form FormModel
+
+	query := `SELECT * FROM forms WHERE uuid=$1 AND deleted_at IS NOT NULL`
+
+	err := pgxscan.Get(ctx, r.db, &form, query, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetTrashedByUUID query: %w", err)
+	}
+
+	return &form, nil
+}
+
+func (r *FormRepository) GetTrashListingByUserID(ctx context.Context, id int) ([]*FormModel, error) {
+	forms := []*FormModel{}
+
+	query := `
+	SELECT uuid, name, description, status, views, created_at, updated_at, deleted_at
+	FROM forms
+	WHERE user_id = $1 AND deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC`
+
+	err := pgxscan.Select(ctx, r.db, &forms, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetTrashListingByUserID query: %w", err)
+	}
+
+	return forms, nil
+}
+
+func (r *FormRepository) RestoreFromTrash(ctx context.Context, id int) (*FormModel, error) {
+	query := `
+		UPDATE forms
+		SET deleted_at=NULL, updated_at=$1
+		WHERE id=$2 AND deleted_at IS NOT NULL
+		RETURNING *
+	`
+
+	var form FormModel
+
+	err := pgxscan.Get(ctx, r.db, &form, query, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("form.RestoreFromTrash query: %w", err)
+	}
+
+	return &form, nil
+}
+
+// PurgeDeletedBefore permanently removes every form trashed before cutoff
+func (r *FormRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM forms WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	tag, err := r.db.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("form.PurgeDeletedBefore: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// insertRevision snapshots the current state of a form under its current
+// revision number, it must run in the same transaction as the update
+func insertRevision(ctx context.Context, tx pgx.Tx, formId int) error {
+	query := `
+		INSERT INTO form_revisions (form_id, revision, name, description, status, form_data, created_at)
+		SELECT
+			f.id,
+			f.revision,
+			f.name,
+			f.description,
+			f.status,
+			f.form_data,
+			$2
+		FROM forms f
+		WHERE f.id = $1
+	`
+
+	_, err := tx.Exec(ctx, query, formId, time.Now())
+	if err != nil {
+		return fmt.Errorf("form.insertRevision: %w", err)
+	}
+
+	return nil
+}
+
+func (r *FormRepository) GetRevisionsByFormID(ctx context.Context, formId int) ([]*RevisionModel, error) {
+	revisions := []*RevisionModel{}
+
+	query := `
+	SELECT id, uuid, form_id, revision, name, description, status, created_at
+	FROM form_revisions
+	WHERE form_id = $1
+	ORDER BY revision DESC`
+
+	err := pgxscan.Select(ctx, r.db, &revisions, query, formId)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetRevisionsByFormID query: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *FormRepository) GetRevision(ctx context.Context, formId int, revision int) (*RevisionModel, error) {
+	var rev RevisionModel
+
+	query := `SELECT * FROM form_revisions WHERE form_id=$1 AND revision=$2`
+
+	err := pgxscan.Get(ctx, r.db, &rev, query, formId, revision)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetRevision query: %w", err)
+	}
+
+	return &rev, nil
+}
+
+// RestoreRevision copies an old revision back onto the form, the restore is
+// itself recorded as a new revision so it can be undone
+func (r *FormRepository) RestoreRevision(ctx context.Context, formId int, expectedRevision int, revision int) (*FormModel, error) {
+	now := time.Now()
+
+	query := `
+		UPDATE forms f
+		SET name=fr.name, description=fr.description, form_data=fr.form_data, updated_at=$3, revision=f.revision + 1
+		FROM form_revisions fr
+		WHERE f.id=$1 AND fr.form_id=f.id AND fr.revision=$2 AND f.revision=$4
+		RETURNING f.*
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.RestoreRevision begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var form FormModel
+
+	err = pgxscan.Get(ctx, tx, &form, query, formId, revision, now, expectedRevision)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			return nil, ErrStaleRevision
+		}
+		return nil, fmt.Errorf("form.RestoreRevision query: %w", err)
+	}
+
+	if err := insertRevision(ctx, tx, form.ID); err != nil {
+		return nil, fmt.Errorf("form.RestoreRevision: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.RestoreRevision commit: %w", err)
+	}
+
+	return &form, nil
+}
+
+// Publish freezes the current draft into a new version and makes it the one
+// served on the public routes
+func (r *FormRepository) Publish(ctx context.Context, formId int) (*VersionModel, error) {
+	now := time.Now()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form.Publish begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// lock the form so concurrent publishes get sequential version numbers
+	_, err = tx.Exec(ctx, `SELECT id FROM forms WHERE id=$1 FOR UPDATE`, formId)
+	if err != nil {
+		return nil, fmt.Errorf("form.Publish lock: %w", err)
+	}
+
+	insert_version := `
+		INSERT INTO form_versions (form_id, version, name, description, form_data, published_at)
+		SELECT
+			f.id,
+			COALESCE((SELECT MAX(fv.version) FROM form_versions fv WHERE fv.form_id = f.id), 0) + 1,
+			f.name,
+			f.description,
+			f.form_data,
+			$2
+		FROM forms f
+		WHERE f.id = $1
+		RETURNING *
+	`
+
+	var version VersionModel
+
+	err = pgxscan.Get(ctx, tx, &version, insert_version, formId, now)
+	if err != nil {
+		return nil, fmt.Errorf("form.Publish insert: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE forms SET published_version_id=$1 WHERE id=$2`, version.ID, formId)
+	if err != nil {
+		return nil, fmt.Errorf("form.Publish update: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("form.Publish commit: %w", err)
+	}
+
+	return &version, nil
+}
+
+func (r *FormRepository) GetVersionByID(ctx context.Context, id int) (*VersionModel, error) {
+	var version VersionModel
+
+	query := `SELECT * FROM form_versions WHERE id=$1`
+
+	err := pgxscan.Get(ctx, r.db, &version, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetVersionByID query: %w", err)
+	}
+
+	return &version, nil
+}
+
+func (r *FormRepository) GetVersionsByFormID(ctx context.Context, formId int) ([]*VersionModel, error) {
+	versions := []*VersionModel{}
+
+	query := `
+	SELECT id, uuid, form_id, version, name, description, published_at
+	FROM form_versions
+	WHERE form_id = $1
+	ORDER BY version DESC`
+
+	err := pgxscan.Select(ctx, r.db, &versions, query, formId)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetVersionsByFormID query: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersionSnapshotsByFormID returns every published version with its form
+// data, newest first
+func (r *FormRepository) GetVersionSnapshotsByFormID(ctx context.Context, formId int) ([]*VersionModel, error) {
+	versions := []*VersionModel{}
+
+	query := `
+	SELECT id, uuid, form_id, version, name, description, form_data, published_at
+	FROM form_versions
+	WHERE form_id = $1
+	ORDER BY version DESC`
+
+	err := pgxscan.Select(ctx, r.db, &versions, query, formId)
+	if err != nil {
+		return nil, fmt.Errorf("form.GetVersionSnapshotsByFormID query: %w", err)
+	}
+
+	return versions, nil
+}
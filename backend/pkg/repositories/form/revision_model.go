@@ -0,0 +1,23 @@
+package form_repo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type RevisionModel struct {
+	ID          int             `json:"-" db:"id"`
+	UUID        string          `json:"uuid" db:"uuid"`
+	FormID      int             `json:"-" db:"form_id"`
+	Revision    int             `json:"revision" db:"revision"`
+	Name        string          `json:"name" db:"name"`
+	Description *string         `json:"description" db:"description"`
+	Status      string          `json:"status" db:"status"`
+	FormData    json.RawMessage `json:"form_data,omitempty" db:"form_data"` // Use json.RawMessage for JSONB
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Helper method to unmarshal FormData into a specific struct
+func (m *RevisionModel) UnmarshalFormData(v interface{}) error {
+	return json.Unmarshal(m.FormData, v)
+}
@@ -0,0 +1,38 @@
+package form_repo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ViewKey buckets buffered views by form and day
+type ViewKey struct {
+	FormID int
+	Day    time.Time
+}
+
+// Visitor is a daily unique visitor to a public form, Hash never contains the
+// visitor's IP or user agent, see views.Counter
+type Visitor struct {
+	FormID int
+	Day    time.Time
+	Hash   string
+}
+
+// DailyStatsModel is one day of activity on a form
+type DailyStatsModel struct {
+	FormID               int             `json:"-" db:"form_id"`
+	Day                  time.Time       `json:"day" db:"day"`
+	Views                int             `json:"views" db:"views"`
+	UniqueViews          int             `json:"unique_views" db:"unique_views"`
+	Starts               int             `json:"starts" db:"starts"`
+	Submissions          int             `json:"submissions" db:"submissions"`
+	AffiliateSubmissions json.RawMessage `json:"affiliate_submissions" db:"affiliate_submissions"` // affiliate uuid -> submissions
+}
+
+// Helper method to unmarshal AffiliateSubmissions into a map
+func (m *DailyStatsModel) GetAffiliateSubmissions() (map[string]int, error) {
+	counts := map[string]int{}
+	err := json.Unmarshal(m.AffiliateSubmissions, &counts)
+	return counts, err
+}
@@ -1,31 +1,51 @@
 package form_repo
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
+
+	"github.com/google/uuid"
 )
 
 var untitledPattern = regexp.MustCompile(`^Untitled(?: (\d+))?$`)
 
 func GenerateFormUntitledName(forms []*FormModel) string {
+	return nextNumberedName("Untitled", untitledPattern, forms)
+}
+
+// GenerateFormCopyName names a duplicate of a form, "Copy of X" then "Copy of X 2" etc.
+func GenerateFormCopyName(name string, forms []*FormModel) string {
+	return GenerateFormNumberedName(fmt.Sprintf("Copy of %s", name), forms)
+}
+
+// GenerateFormNumberedName returns base, or "base N" if base is already taken
+func GenerateFormNumberedName(base string, forms []*FormModel) string {
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(base) + `(?: (\d+))?$`)
+	return nextNumberedName(base, pattern, forms)
+}
+
+// nextNumberedName finds the highest "<base> X" in use and returns the next one,
+// pattern must capture the number in its first group
+func nextNumberedName(base string, pattern *regexp.Regexp, forms []*FormModel) string {
 	if len(forms) == 0 {
-		return "Untitled"
+		return base
 	}
 
-	// Find highest number in "Untitled X" pattern
+	// Find highest number in "<base> X" pattern
 	maxNumber := 0
 
 	for _, form := range forms {
-		matches := untitledPattern.FindStringSubmatch(form.Name)
+		matches := pattern.FindStringSubmatch(form.Name)
 		if matches != nil {
 			if matches[1] == "" {
-				// Just "Untitled" with no number = Untitled 1
+				// Just "<base>" with no number = <base> 1
 				if maxNumber < 1 {
 					maxNumber = 1
 				}
 			} else {
-				// "Untitled X"
+				// "<base> X"
 				num, _ := strconv.Atoi(matches[1])
 				if num > maxNumber {
 					maxNumber = num
@@ -36,7 +56,80 @@ func GenerateFormUntitledName(forms []*FormModel) string {
 
 	// Generate next number
 	if maxNumber == 0 {
-		return "Untitled"
+		return base
+	}
+	return fmt.Sprintf("%s %d", base, maxNumber+1)
+}
+
+// CloneWithNewUUIDs deep copies form data giving every step, field and option
+// a fresh UUID, condition rules are rewritten to point at the new IDs
+func CloneWithNewUUIDs(formData *FormData) (*FormData, error) {
+	// round trip through json for a deep copy, this also copies pointers and
+	// the interface{} values held in condition rules
+	b, err := json.Marshal(formData)
+	if err != nil {
+		return nil, fmt.Errorf("form.CloneWithNewUUIDs marshal: %w", err)
+	}
+
+	var clone FormData
+	if err := json.Unmarshal(b, &clone); err != nil {
+		return nil, fmt.Errorf("form.CloneWithNewUUIDs unmarshal: %w", err)
+	}
+
+	ids := map[string]string{}
+
+	// time ordered like uuid_generate_v7() in the db
+	newID := func() (string, error) {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return "", fmt.Errorf("form.CloneWithNewUUIDs uuid: %w", err)
+		}
+		return id.String(), nil
+	}
+
+	for i := range clone.Steps {
+		step := &clone.Steps[i]
+		if ids[step.UUID], err = newID(); err != nil {
+			return nil, err
+		}
+		step.UUID = ids[step.UUID]
+
+		for j := range step.Fields {
+			field := &step.Fields[j]
+			if ids[field.UUID], err = newID(); err != nil {
+				return nil, err
+			}
+			field.UUID = ids[field.UUID]
+
+			for k := range field.Options {
+				if field.Options[k].UUID, err = newID(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	rewrite := func(cond *Condition) {
+		if cond == nil {
+			return
+		}
+		for i := range cond.Conditions {
+			if id, ok := ids[cond.Conditions[i].UUID]; ok {
+				cond.Conditions[i].UUID = id
+			}
+		}
 	}
-	return fmt.Sprintf("Untitled %d", maxNumber+1)
+
+	for i := range clone.Steps {
+		rewrite(clone.Steps[i].Condition)
+		for j := range clone.Steps[i].Fields {
+			rewrite(clone.Steps[i].Fields[j].Condition)
+		}
+	}
+
+	if clone.Steps == nil {
+		clone.Steps = []Step{}
+	}
+
+	return &clone, nil
 }
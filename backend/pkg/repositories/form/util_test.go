@@ -0,0 +1,56 @@
+package form_repo_test
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+	"testing"
+)
+
+func TestGenerateFormCopyName(t *testing.T) {
+	forms := []*form_repo.FormModel{{Name: "Leads (EU)"}}
+
+	if got := form_repo.GenerateFormCopyName("Leads (EU)", forms); got != "Copy of Leads (EU)" {
+		t.Errorf("expected first copy name, got %q", got)
+	}
+
+	forms = append(forms, &form_repo.FormModel{Name: "Copy of Leads (EU)"})
+
+	if got := form_repo.GenerateFormCopyName("Leads (EU)", forms); got != "Copy of Leads (EU) 2" {
+		t.Errorf("expected numbered copy name, got %q", got)
+	}
+}
+
+func TestCloneWithNewUUIDs(t *testing.T) {
+	original := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{UUID: "s1", Fields: []form_repo.Field{
+				{UUID: "f1", Options: []form_repo.Option{{UUID: "o1", Value: "yes"}}},
+				{UUID: "f2", Condition: &form_repo.Condition{Operator: "AND", Conditions: []form_repo.CondRule{
+					{Type: "field", UUID: "f1", Operator: "equals", Value: "yes"},
+				}}},
+			}},
+			{UUID: "s2", Condition: &form_repo.Condition{Operator: "AND", Conditions: []form_repo.CondRule{
+				{Type: "step", UUID: "s1", Operator: "isCompleted"},
+			}}},
+		},
+	}
+
+	clone, err := form_repo.CloneWithNewUUIDs(original)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	s1, s2 := clone.Steps[0], clone.Steps[1]
+	f1, f2 := s1.Fields[0], s1.Fields[1]
+
+	if s1.UUID == "s1" || f1.UUID == "f1" || f1.Options[0].UUID == "o1" {
+		t.Error("expected UUIDs to be regenerated")
+	}
+
+	if f2.Condition.Conditions[0].UUID != f1.UUID || s2.Condition.Conditions[0].UUID != s1.UUID {
+		t.Error("expected condition rules to point at the new UUIDs")
+	}
+
+	if original.Steps[0].UUID != "s1" || original.Steps[0].Fields[1].Condition.Conditions[0].UUID != "f1" {
+		t.Error("expected the original form data to be untouched")
+	}
+}
@@ -0,0 +1,24 @@
+package form_repo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// VersionModel is an immutable published snapshot of a form, public views and
+// submissions are always served from one of these rather than the draft
+type VersionModel struct {
+	ID          int             `json:"-" db:"id"`
+	UUID        string          `json:"uuid" db:"uuid"`
+	FormID      int             `json:"-" db:"form_id"`
+	Version     int             `json:"version" db:"version"`
+	Name        string          `json:"name" db:"name"`
+	Description *string         `json:"description" db:"description"`
+	FormData    json.RawMessage `json:"form_data,omitempty" db:"form_data"` // Use json.RawMessage for JSONB
+	PublishedAt time.Time       `json:"published_at" db:"published_at"`
+}
+
+// Helper method to unmarshal FormData into a specific struct
+func (m *VersionModel) UnmarshalFormData(v interface{}) error {
+	return json.Unmarshal(m.FormData, v)
+}
@@ -0,0 +1,12 @@
+package submission_repo
+
+// the listing helpers, exported for the tests in submission_repo_test
+var (
+	Filters           = filters
+	BuildListingQuery = buildListingQuery
+	Highlight         = highlight
+)
+
+func (q *ListingQuery) Normalize() error {
+	return q.normalize()
+}
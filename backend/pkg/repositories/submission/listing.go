@@ -0,0 +1,270 @@
+package submission_repo
+
+import (
+	"errors"
+	"fmt"
+	"formaura/pkg/keyset"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Listing sort keys, every sort is tie broken on uuid which is a UUIDv7 and so
+// also orders by submission
+const (
+	SortSubmitted = "submitted"
+	SortName      = "name"
+	SortEmail     = "email"
+	SortRelevance = "relevance"
+)
+
+var ValidSorts = []string{SortSubmitted, SortName, SortEmail, SortRelevance}
+
+// name and email are optional, blanks sort as empty strings so they can be
+// compared in a cursor
+var sortColumns = map[string]string{
+	SortSubmitted: "s.submitted_at",
+	SortName:      "COALESCE(s.full_name, '')",
+	SortEmail:     "COALESCE(s.email, '')",
+	// bound per query, see buildListingQuery
+	SortRelevance: "",
+}
+
+const (
+	DefaultListingLimit = 50
+	MaxListingLimit     = 100
+)
+
+var ErrInvalidCursor = keyset.ErrInvalidCursor
+
+// ErrSearchRequired is returned when sorting by relevance without a search
+var ErrSearchRequired = errors.New("submission: relevance sort requires a search")
+
+// ListingQuery filters, sorts and pages a form's submissions
+type ListingQuery struct {
+	Filter
+	Sort   string
+	Asc    bool
+	Cursor string
+	Limit  int
+}
+
+type Listing struct {
+	Submissions []*Model `json:"submissions"`
+	Total       int      `json:"total"`
+	NextCursor  *string  `json:"next_cursor"`
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func encodeCursor(q *ListingQuery, last *Model) string {
+	c := keyset.Cursor{Sort: q.Sort, Asc: q.Asc, UUID: last.UUID}
+
+	switch q.Sort {
+	case SortSubmitted:
+		c.Value = keyset.FormatTime(last.SubmittedAt)
+	case SortName:
+		c.Value = derefString(last.FullName)
+	case SortEmail:
+		c.Value = derefString(last.Email)
+	case SortRelevance:
+		if last.Rank != nil {
+			c.Value = keyset.FormatFloat(*last.Rank)
+		}
+	}
+
+	return c.Encode()
+}
+
+// decodeCursor returns the sort value and uuid to continue after
+func decodeCursor(q *ListingQuery) (any, string, error) {
+	c, err := keyset.Decode(q.Cursor, q.Sort, q.Asc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var value any
+
+	switch q.Sort {
+	case SortSubmitted:
+		value, err = c.Time()
+	case SortRelevance:
+		value, err = c.Float()
+	default:
+		value = c.Value
+	}
+
+	return value, c.UUID, err
+}
+
+// normalize fills in defaults and checks the sort, searches default to the
+// best matches first
+func (q *ListingQuery) normalize() error {
+	q.Search = searchTerms(q.Search)
+	if q.SearchLanguage == "" {
+		q.SearchLanguage = "simple"
+	}
+	if q.Sort == "" {
+		q.Sort = SortSubmitted
+		if q.Search != "" {
+			q.Sort = SortRelevance
+		}
+	}
+	if _, ok := sortColumns[q.Sort]; !ok {
+		return fmt.Errorf("submission: unknown sort %q", q.Sort)
+	}
+	if q.Sort == SortRelevance && q.Search == "" {
+		return ErrSearchRequired
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultListingLimit
+	}
+	if q.Limit > MaxListingLimit {
+		q.Limit = MaxListingLimit
+	}
+	return nil
+}
+
+// filters builds the WHERE clause for a form's submissions, the form id must
+// already be bound as $1
+func filters(f Filter, args []any) (string, []any) {
+	where := []string{"s.form_id = $1"}
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.AffiliateUUID != nil {
+		where = append(where, "s.affiliate_id = (SELECT id FROM affiliates WHERE uuid = "+arg(*f.AffiliateUUID)+")")
+	}
+	if email := strings.TrimSpace(f.Email); email != "" {
+		where = append(where, "lower(s.email) = lower("+arg(email)+")")
+	}
+	if f.From != nil {
+		where = append(where, "s.submitted_at >= "+arg(*f.From))
+	}
+	if f.Before != nil {
+		where = append(where, "s.submitted_at < "+arg(*f.Before))
+	}
+
+	if search := searchTerms(f.Search); search != "" {
+		where = append(where, "s.search_vector @@ "+tsQuery(f.SearchLanguage, search, arg))
+	}
+
+	// sorted so the same filters always build the same query
+	keys := make([]string, 0, len(f.Fields))
+	for key := range f.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		k, v := arg(key), arg(f.Fields[key])
+		// numbers and booleans match on their text, arrays on containing the value
+		where = append(where, fmt.Sprintf(
+			"(s.submission_data ->> %s = %s OR s.submission_data -> %s @> to_jsonb(%s::text))", k, v, k, v,
+		))
+	}
+
+	return strings.Join(where, " AND "), args
+}
+
+// searchTerms trims a search, one without any letters or digits, eg. `-` or
+// `""`, is returned empty as websearch_to_tsquery would find nothing to match
+// and every submission would be filtered out
+func searchTerms(search string) string {
+	search = strings.TrimSpace(search)
+	if strings.IndexFunc(search, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return search
+}
+
+// tsQuery parses a web style search, eg. "acme -ltd" or "\"big order\"". The
+// config is bound rather than read from the row so the GIN index can be used.
+func tsQuery(language, search string, arg func(v any) string) string {
+	if language == "" {
+		language = "simple"
+	}
+	return fmt.Sprintf("websearch_to_tsquery(%s::regconfig, %s)", arg(language), arg(search))
+}
+
+// buildListingQuery returns the page query, it fetches one row more than the
+// limit so the caller can tell whether there is a next page
+func buildListingQuery(formId int, q *ListingQuery) (string, []any, error) {
+	where, args := filters(q.Filter, []any{formId})
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	selectColumns := columns
+	column := sortColumns[q.Sort]
+
+	if q.Search != "" {
+		rank := fmt.Sprintf("ts_rank(s.search_vector, %s)::float8", tsQuery(q.SearchLanguage, q.Search, arg))
+		selectColumns += ", " + rank + " AS rank"
+		if q.Sort == SortRelevance {
+			column = rank
+		}
+	}
+
+	dir, cmp := keyset.Direction(q.Asc)
+
+	if q.Cursor != "" {
+		value, uuid, err := decodeCursor(q)
+		if err != nil {
+			return "", nil, err
+		}
+		where += fmt.Sprintf(" AND (%s, s.uuid) %s (%s, %s)", column, cmp, arg(value), arg(uuid))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT %s
+	FROM form_submissions s
+	WHERE %s
+	ORDER BY %s %s, s.uuid %s
+	LIMIT %s`, selectColumns, where, column, dir, dir, arg(q.Limit+1))
+
+	return query, args, nil
+}
+
+// highlight markers are control characters so they cannot clash with answers,
+// they become <mark> tags once the rest of the text has been html escaped
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+var highlighter = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+func highlight(headline string) string {
+	return highlighter.Replace(html.EscapeString(headline))
+}
+
+// headlinesQuery builds snippets of the name, email and text answers around
+// the search matches for the given submission ids, it is only run for a page
+// since ts_headline reparses the whole document
+const headlinesQuery = `
+	SELECT
+	s.id,
+	ts_headline(
+		s.search_language,
+		concat_ws(' … ', s.full_name, s.email, (
+			SELECT string_agg(v #>> '{}', ' … ')
+			FROM jsonb_path_query(s.submission_data, 'strict $.**') v
+			WHERE jsonb_typeof(v) = 'string'
+		)),
+		websearch_to_tsquery(s.search_language, $2),
+		'StartSel=` + highlightStart + `, StopSel=` + highlightStop + `, MaxFragments=3, FragmentDelimiter=" … "'
+	) AS headline
+	FROM form_submissions s
+	WHERE s.id = ANY($1)`
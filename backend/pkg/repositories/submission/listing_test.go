@@ -0,0 +1,141 @@
+package submission_repo_test
+
+import (
+	"errors"
+	"formaura/pkg/keyset"
+	submission_repo "formaura/pkg/repositories/submission"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// field filters bind both the field uuid and the answer, in key order
+func TestFieldFilters(t *testing.T) {
+	filter := submission_repo.Filter{Fields: map[string]string{"b'; DROP TABLE x; --": "yes", "a": "1"}}
+
+	where, args := submission_repo.Filters(filter, []any{7})
+
+	want := "s.form_id = $1" +
+		" AND (s.submission_data ->> $2 = $3 OR s.submission_data -> $2 @> to_jsonb($3::text))" +
+		" AND (s.submission_data ->> $4 = $5 OR s.submission_data -> $4 @> to_jsonb($5::text))"
+
+	if where != want {
+		t.Errorf("expected where\n%s\ngot\n%s", want, where)
+	}
+
+	if wantArgs := []any{7, "a", "1", "b'; DROP TABLE x; --", "yes"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("expected args %v, got %v", wantArgs, args)
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	cases := map[string]struct {
+		search string
+		where  string
+		args   []any
+	}{
+		"empty":          {"", "s.form_id = $1", []any{7}},
+		"blank":          {"  \t", "s.form_id = $1", []any{7}},
+		"only operators": {` - "" `, "s.form_id = $1", []any{7}},
+		"words": {
+			" acme -ltd ",
+			"s.form_id = $1 AND s.search_vector @@ websearch_to_tsquery($2::regconfig, $3)",
+			[]any{7, "english", "acme -ltd"},
+		},
+		// quotes are left for websearch_to_tsquery to parse, never put in the sql
+		"quotes": {
+			`"big order" o'brien`,
+			"s.form_id = $1 AND s.search_vector @@ websearch_to_tsquery($2::regconfig, $3)",
+			[]any{7, "english", `"big order" o'brien`},
+		},
+		"unbalanced quote": {
+			`"big order`,
+			"s.form_id = $1 AND s.search_vector @@ websearch_to_tsquery($2::regconfig, $3)",
+			[]any{7, "english", `"big order`},
+		},
+	}
+
+	for name, c := range cases {
+		where, args := submission_repo.Filters(submission_repo.Filter{Search: c.search, SearchLanguage: "english"}, []any{7})
+
+		if where != c.where {
+			t.Errorf("%s: expected where\n%s\ngot\n%s", name, c.where, where)
+		}
+
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%s: expected args %v, got %v", name, c.args, args)
+		}
+	}
+
+	// a search without words does not default to sorting by relevance
+	q := &submission_repo.ListingQuery{Filter: submission_repo.Filter{Search: "--"}}
+	if err := q.Normalize(); err != nil || q.Sort != submission_repo.SortSubmitted || q.Search != "" {
+		t.Errorf("expected a plain listing, got %+v (%v)", q, err)
+	}
+
+	q = &submission_repo.ListingQuery{Filter: submission_repo.Filter{Search: "acme"}}
+	if err := q.Normalize(); err != nil || q.Sort != submission_repo.SortRelevance || q.SearchLanguage != "simple" {
+		t.Errorf("expected a relevance listing, got %+v (%v)", q, err)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	cases := map[string]struct {
+		headline string
+		want     string
+	}{
+		"plain":   {"no matches", "no matches"},
+		"match":   {"big \x02order\x03 today", "big <mark>order</mark> today"},
+		"escaped": {"<b>\x02acme\x03</b> & \"co\"", "&lt;b&gt;<mark>acme</mark>&lt;/b&gt; &amp; &#34;co&#34;"},
+		// markup typed into an answer never becomes a tag
+		"fake mark": {"<mark>x</mark> \x02y\x03", "&lt;mark&gt;x&lt;/mark&gt; <mark>y</mark>"},
+		"several":   {"\x02a\x03 … \x02b\x03", "<mark>a</mark> … <mark>b</mark>"},
+	}
+
+	for name, c := range cases {
+		if got := submission_repo.Highlight(c.headline); got != c.want {
+			t.Errorf("%s: expected %q, got %q", name, c.want, got)
+		}
+	}
+}
+
+func TestRelevanceSort(t *testing.T) {
+	q := &submission_repo.ListingQuery{Filter: submission_repo.Filter{Search: "acme"}}
+	if err := q.Normalize(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	q.Cursor = (&keyset.Cursor{Sort: q.Sort, Value: keyset.FormatFloat(0.0607927), UUID: "last"}).Encode()
+
+	query, args, err := submission_repo.BuildListingQuery(7, q)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	rank := "ts_rank(s.search_vector, websearch_to_tsquery($4::regconfig, $5))::float8"
+
+	for _, want := range []string{
+		rank + " AS rank",
+		"AND (" + rank + ", s.uuid) < ($6, $7)",
+		"ORDER BY " + rank + " DESC, s.uuid DESC",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in\n%s", want, query)
+		}
+	}
+
+	if args[5] != 0.0607927 || args[6] != "last" {
+		t.Errorf("expected the rank cursor to be bound, got %v", args)
+	}
+
+	// a rank that would sort past every real one is not a position
+	q.Cursor = (&keyset.Cursor{Sort: q.Sort, Value: "NaN", UUID: "last"}).Encode()
+	if _, _, err := submission_repo.BuildListingQuery(7, q); !errors.Is(err, submission_repo.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	q = &submission_repo.ListingQuery{Sort: submission_repo.SortRelevance}
+	if err := q.Normalize(); !errors.Is(err, submission_repo.ErrSearchRequired) {
+		t.Errorf("expected ErrSearchRequired, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package submission_repo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Model struct {
+	ID             int             `json:"-" db:"id"`
+	UUID           string          `json:"uuid" db:"uuid"`
+	FormID         int             `json:"-" db:"form_id"`
+	AffiliateID    *int            `json:"-" db:"affiliate_id"`
+	AffiliateUUID  *string         `json:"affiliate_uuid" db:"affiliate_uuid"`
+	FormVersionID  *int            `json:"-" db:"form_version_id"`
+	FullName       *string         `json:"full_name" db:"full_name"`
+	Email          *string         `json:"email" db:"email"`
+	SubmissionData json.RawMessage `json:"submission_data" db:"submission_data"` // Use json.RawMessage for JSONB
+	SubmittedAt    time.Time       `json:"submitted_at" db:"submitted_at"`
+	// set when the listing is searched
+	Rank     *float64 `json:"rank,omitempty" db:"rank"`
+	Headline *string  `json:"headline,omitempty" db:"-"`
+}
+
+// Helper method to unmarshal SubmissionData into a specific struct
+func (m *Model) UnmarshalSubmissionData(v interface{}) error {
+	return json.Unmarshal(m.SubmissionData, v)
+}
+
+// Filter narrows the submissions a listing or report is built from, empty
+// fields do not filter
+type Filter struct {
+	AffiliateUUID *string
+	Email         string
+	From          *time.Time // inclusive
+	Before        *time.Time // exclusive
+	// answers keyed by field uuid, checkbox answers match if they include the value
+	Fields map[string]string
+	// full text search in the form's search language, see form_repo.SearchLanguages
+	Search         string
+	SearchLanguage string
+}
+
+// AnswerCount is the number of submissions that gave Value for a field,
+// checkbox arrays are counted once per ticked option. The row with Total set
+// is the number of submissions that answered the field at all.
+type AnswerCount struct {
+	FieldUUID string  `db:"field_uuid"`
+	Value     *string `db:"value"`
+	Total     bool    `db:"total"`
+	Count     int     `db:"count"`
+}
@@ -0,0 +1,287 @@
+package submission_repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"formaura/pkg/db"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type Repository interface {
+	Create(ctx context.Context, formId int, versionId int, affiliateUUID *string, fullName, email *string, data map[string]any) (*Model, error)
+	GetByUUID(ctx context.Context, uuid string) (*Model, error)
+	GetListingByFormID(ctx context.Context, formId int) ([]*Model, error)
+	GetPageByFormID(ctx context.Context, formId int, q ListingQuery) (*Listing, error)
+	StreamByFormID(ctx context.Context, formId int, f Filter, fn func(*Model) error) error
+	CountByFormID(ctx context.Context, formId int, f Filter) (int, error)
+	GetAnswerCounts(ctx context.Context, formId int, fieldUUIDs []string, f Filter) ([]*AnswerCount, error)
+}
+
+// columns selects a submission with the uuid of its affiliate, it expects the
+// submission to be aliased as s. The search columns are left out, they are
+// only used in WHERE clauses.
+const columns = `s.id, s.uuid, s.form_id, s.affiliate_id, s.form_version_id,
+	s.full_name, s.email, s.submission_data, s.submitted_at,
+	(SELECT a.uuid FROM affiliates a WHERE a.id = s.affiliate_id) AS affiliate_uuid`
+
+type SubmissionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSubmissionRepo(db *pgxpool.Pool) *SubmissionRepository {
+	return &SubmissionRepository{db: db}
+}
+
+func (r *SubmissionRepository) Create(ctx context.Context, formId int, versionId int, affiliateUUID *string, fullName, email *string, data map[string]any) (*Model, error) {
+	// submitted_at is a UTC timestamp, its date is the day in form_daily_stats
+	now := time.Now().UTC()
+
+	// Marshal submission data to JSON
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("submission.Create marshal: %w", err)
+	}
+
+	// the affiliate is resolved through form_affiliates so a submission can only
+	// ever be attributed to an affiliate that is attached to the form
+	query := `
+		INSERT INTO form_submissions AS s (form_id, form_version_id, affiliate_id, full_name, email, submission_data, submitted_at, search_language)
+		VALUES (
+			$1,
+			$2,
+			(SELECT a.id FROM affiliates a
+				JOIN form_affiliates fa ON fa.affiliate_id = a.id
+				WHERE a.uuid = $3 AND fa.form_id = $1),
+			$4, $5, $6, $7,
+			(SELECT f.search_language::regconfig FROM forms f WHERE f.id = $1)
+		)
+		RETURNING ` + columns
+
+	var submission Model
+
+	err = pgxscan.Get(ctx, r.db, &submission, query, formId, versionId, affiliateUUID, fullName, email, dataJSON, now)
+	if err != nil {
+		return nil, fmt.Errorf("submission.Create query: %w", err)
+	}
+
+	return &submission, nil
+}
+
+func (r *SubmissionRepository) GetByUUID(ctx context.Context, uuid string) (*Model, error) {
+	var submission Model
+
+	query := `SELECT ` + columns + ` FROM form_submissions s WHERE s.uuid=$1`
+
+	err := pgxscan.Get(ctx, r.db, &submission, query, uuid)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			return nil, fmt.Errorf("submission.GetByUUID not found: %s", uuid)
+		}
+		return nil, fmt.Errorf("submission.GetByUUID query: %w", err)
+	}
+
+	return &submission, nil
+}
+
+func (r *SubmissionRepository) GetListingByFormID(ctx context.Context, formId int) ([]*Model, error) {
+	submissions := []*Model{}
+
+	query := `
+	SELECT ` + columns + `
+	FROM form_submissions s
+	WHERE s.form_id = $1
+	ORDER BY s.submitted_at DESC`
+
+	err := pgxscan.Select(ctx, r.db, &submissions, query, formId)
+	if err != nil {
+		return nil, fmt.Errorf("submission.GetListingByFormID query: %w", err)
+	}
+
+	return submissions, nil
+}
+
+// GetPageByFormID returns one page of a form's submissions along with the
+// total matching the filters and a cursor for the next page
+func (r *SubmissionRepository) GetPageByFormID(ctx context.Context, formId int, q ListingQuery) (*Listing, error) {
+	if err := q.normalize(); err != nil {
+		return nil, err
+	}
+
+	query, args, err := buildListingQuery(formId, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := &Listing{Submissions: []*Model{}}
+
+	err = pgxscan.Select(ctx, r.db, &listing.Submissions, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("submission.GetPageByFormID query: %w", err)
+	}
+
+	listing.Total, err = r.CountByFormID(ctx, formId, q.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Search != "" {
+		if err := r.addHeadlines(ctx, listing.Submissions, q.Search); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(listing.Submissions) > q.Limit {
+		listing.Submissions = listing.Submissions[:q.Limit]
+
+		cursor := encodeCursor(&q, listing.Submissions[q.Limit-1])
+		listing.NextCursor = &cursor
+	}
+
+	return listing, nil
+}
+
+// streamBatchSize is how many rows StreamByFormID fetches from its cursor at a time
+const streamBatchSize = 500
+
+// StreamByFormID calls fn with each of a form's submissions, oldest first.
+// Rows are read through a server side cursor in batches so exports of any
+// size run in constant memory, an error from fn stops the stream.
+func (r *SubmissionRepository) StreamByFormID(ctx context.Context, formId int, f Filter, fn func(*Model) error) error {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("submission.StreamByFormID begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	where, args := filters(f, []any{formId})
+
+	declare := `
+	DECLARE submission_stream NO SCROLL CURSOR FOR
+	SELECT ` + columns + `
+	FROM form_submissions s
+	WHERE ` + where + `
+	ORDER BY s.submitted_at, s.uuid`
+
+	if _, err := tx.Exec(ctx, declare, args...); err != nil {
+		return fmt.Errorf("submission.StreamByFormID declare: %w", err)
+	}
+
+	fetch := fmt.Sprintf(`FETCH %d FROM submission_stream`, streamBatchSize)
+
+	for {
+		batch := []*Model{}
+
+		if err := pgxscan.Select(ctx, tx, &batch, fetch); err != nil {
+			return fmt.Errorf("submission.StreamByFormID fetch: %w", err)
+		}
+
+		for _, submission := range batch {
+			if err := fn(submission); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < streamBatchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// addHeadlines sets the highlighted search snippet of each submission, the
+// text is html escaped apart from the <mark> tags around matches
+func (r *SubmissionRepository) addHeadlines(ctx context.Context, submissions []*Model, search string) error {
+	if len(submissions) == 0 {
+		return nil
+	}
+
+	byID := map[int]*Model{}
+	ids := make([]int, 0, len(submissions))
+	for _, s := range submissions {
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := r.db.Query(ctx, headlinesQuery, ids, search)
+	if err != nil {
+		return fmt.Errorf("submission.addHeadlines query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var headline string
+		if err := rows.Scan(&id, &headline); err != nil {
+			return fmt.Errorf("submission.addHeadlines scan: %w", err)
+		}
+		headline = highlight(headline)
+		byID[id].Headline = &headline
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("submission.addHeadlines rows: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SubmissionRepository) CountByFormID(ctx context.Context, formId int, f Filter) (int, error) {
+	where, args := filters(f, []any{formId})
+
+	query := `SELECT count(*) FROM form_submissions s WHERE ` + where
+
+	var count int
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("submission.CountByFormID query: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetAnswerCounts counts the distinct answers given to each of the fields, the
+// ?| filter lets the GIN index on submission_data skip submissions that
+// answered none of them
+func (r *SubmissionRepository) GetAnswerCounts(ctx context.Context, formId int, fieldUUIDs []string, f Filter) ([]*AnswerCount, error) {
+	counts := []*AnswerCount{}
+
+	if len(fieldUUIDs) == 0 {
+		return counts, nil
+	}
+
+	where, args := filters(f, []any{formId, fieldUUIDs})
+
+	query := `
+	SELECT
+	a.key AS field_uuid,
+	v.value,
+	GROUPING(v.value) = 1 AS total,
+	count(DISTINCT s.id) AS count
+	FROM form_submissions s
+	CROSS JOIN LATERAL jsonb_each(s.submission_data) a
+	CROSS JOIN LATERAL (
+		SELECT jsonb_array_elements_text(a.value) AS value
+		WHERE jsonb_typeof(a.value) = 'array'
+		UNION ALL
+		SELECT a.value #>> '{}'
+		WHERE jsonb_typeof(a.value) NOT IN ('array', 'object', 'null')
+	) v
+	WHERE ` + where + `
+	AND s.submission_data ?| $2
+	AND a.key = ANY($2)
+	GROUP BY GROUPING SETS ((a.key), (a.key, v.value))`
+
+	err := pgxscan.Select(ctx, r.db, &counts, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("submission.GetAnswerCounts query: %w", err)
+	}
+
+	return counts, nil
+}
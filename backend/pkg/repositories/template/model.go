@@ -0,0 +1,22 @@
+package template_repo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Model is a private template a user saved from one of their forms
+type Model struct {
+	ID          int             `json:"-" db:"id"`
+	UUID        string          `json:"uuid" db:"uuid"`
+	UserID      int             `json:"-" db:"user_id"`
+	Name        string          `json:"name" db:"name"`
+	Description *string         `json:"description" db:"description"`
+	FormData    json.RawMessage `json:"form_data" db:"form_data"` // Use json.RawMessage for JSONB
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Helper method to unmarshal FormData into a specific struct
+func (m *Model) UnmarshalFormData(v interface{}) error {
+	return json.Unmarshal(m.FormData, v)
+}
@@ -0,0 +1,94 @@
+package template_repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"formaura/pkg/db"
+	form_repo "formaura/pkg/repositories/form"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type Repository interface {
+	Create(ctx context.Context, userId int, name string, description *string, formData form_repo.FormData) (*Model, error)
+	GetByUUID(ctx context.Context, uuid string) (*Model, error)
+	GetListingByUserID(ctx context.Context, userId int) ([]*Model, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type TemplateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTemplateRepo(db *pgxpool.Pool) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+func (r *TemplateRepository) Create(ctx context.Context, userId int, name string, description *string, formData form_repo.FormData) (*Model, error) {
+	formDataJSON, err := json.Marshal(formData)
+	if err != nil {
+		return nil, fmt.Errorf("template.Create marshal: %w", err)
+	}
+
+	query := `
+		INSERT INTO form_templates (user_id, name, description, form_data, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *
+	`
+
+	var template Model
+
+	err = pgxscan.Get(ctx, r.db, &template, query, userId, name, description, formDataJSON, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("template.Create query: %w", err)
+	}
+
+	return &template, nil
+}
+
+func (r *TemplateRepository) GetByUUID(ctx context.Context, uuid string) (*Model, error) {
+	var template Model
+
+	query := `SELECT * FROM form_templates WHERE uuid=$1`
+
+	err := pgxscan.Get(ctx, r.db, &template, query, uuid)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			return nil, fmt.Errorf("template.GetByUUID not found: %s", uuid)
+		}
+		return nil, fmt.Errorf("template.GetByUUID query: %w", err)
+	}
+
+	return &template, nil
+}
+
+func (r *TemplateRepository) GetListingByUserID(ctx context.Context, userId int) ([]*Model, error) {
+	templates := []*Model{}
+
+	query := `
+	SELECT *
+	FROM form_templates
+	WHERE user_id = $1
+	ORDER BY created_at DESC`
+
+	err := pgxscan.Select(ctx, r.db, &templates, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("template.GetListingByUserID query: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM form_templates WHERE id=$1`
+
+	_, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("template.Delete: %w", err)
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ type Repository interface {
 	DoesEmailExist(ctx context.Context, email string) (bool, error)
 	GetByEmail(ctx context.Context, email string) (*Model, error)
 	GetByUUID(ctx context.Context, uuid string) (*Model, error)
+	GetByID(ctx context.Context, id int) (*Model, error)
 	FetchAll(ctx context.Context) ([]*Model, error)
 	UpdateEmailConfirmed(ctx context.Context, uuid string, confirmed bool) error
 	UpdateOTP(ctx context.Context, uuid string, otp string) error
@@ -100,6 +101,20 @@ func (r *UserRepository) GetByUUID(ctx context.Context, uuid string) (*Model, er
 	return &user, nil
 }
 
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*Model, error) {
+	var user Model
+	query := `SELECT * FROM users WHERE id=$1`
+
+	err := pgxscan.Get(ctx, r.db, &user, query, id)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("user.GetByID query: %w", err)
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) FetchAll(ctx context.Context) ([]*Model, error) {
 	var users []*Model
 	query := `SELECT * FROM users`
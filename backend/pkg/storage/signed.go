@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("storage: invalid signature")
+	ErrLinkExpired      = errors.New("storage: link expired")
+)
+
+// Secret is the key download links are signed with, set with
+// DOWNLOAD_URL_SECRET. Without it a random key is used, so links handed out
+// stop working whenever the process restarts.
+func Secret() []byte {
+	if secret := os.Getenv("DOWNLOAD_URL_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("storage: unable to generate download secret: %v", err)
+	}
+	return secret
+}
+
+// BaseURL is the public address of the api that links are made absolute
+// with, set with API_BASE_URL. Without it links are relative to the host.
+func BaseURL() string {
+	return strings.TrimSuffix(os.Getenv("API_BASE_URL"), "/")
+}
+
+// Signer makes links to a path that anyone can follow until they expire,
+// without being signed in
+type Signer struct {
+	secret  []byte
+	baseURL string
+}
+
+func NewSigner(secret []byte, baseURL string) *Signer {
+	return &Signer{secret: secret, baseURL: baseURL}
+}
+
+// URL signs path, eg. /api/exports/{uuid}/download, until expires
+func (s *Signer) URL(path string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+
+	params := url.Values{}
+	params.Set("expires", exp)
+	params.Set("signature", s.sign(path, exp))
+
+	return s.baseURL + path + "?" + params.Encode()
+}
+
+// Verify checks the expires and signature params of a request to path
+func (s *Signer) Verify(path string, params url.Values, now time.Time) error {
+	exp := params.Get("expires")
+
+	given, err := base64.RawURLEncoding.DecodeString(params.Get("signature"))
+	if err != nil || exp == "" {
+		return ErrInvalidSignature
+	}
+
+	expected, _ := base64.RawURLEncoding.DecodeString(s.sign(path, exp))
+	if !hmac.Equal(given, expected) {
+		return ErrInvalidSignature
+	}
+
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if now.Unix() >= expires {
+		return ErrLinkExpired
+	}
+
+	return nil
+}
+
+func (s *Signer) sign(path, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(path + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
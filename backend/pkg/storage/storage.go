@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage keeps generated files, eg. submission exports, until they are
+// deleted. Keys are flat names chosen by the caller.
+type Storage interface {
+	// Put stores everything read from r under key, the file only becomes
+	// visible once it is complete
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Dir is where Disk keeps files, set with EXPORT_STORAGE_DIR. It defaults to
+// a directory under the system temp dir, which does not survive a reboot.
+func Dir() string {
+	if dir := os.Getenv("EXPORT_STORAGE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "formaura-exports")
+}
+
+// Disk stores files in a local directory, it is only suitable when the api
+// runs on a single host
+type Disk struct {
+	dir string
+}
+
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	return &Disk{dir: dir}, nil
+}
+
+func (d *Disk) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || key == "." || key == ".." {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(d.dir, key), nil
+}
+
+// Put writes to a temporary file first and renames it into place, so a
+// reader never sees a partial file
+func (d *Disk) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(d.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("storage: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Disk) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete removes the file, a key that does not exist is not an error
+func (d *Disk) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: %w", err)
+	}
+	return nil
+}
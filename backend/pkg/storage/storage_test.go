@@ -0,0 +1,111 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"formaura/pkg/storage"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const path = "/api/exports/0b6b1c9e-3f4a-4c1e-9d55-4f3a1b2c3d4e/download"
+
+func params(t *testing.T, link string) url.Values {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestSignerURL(t *testing.T) {
+	signer := storage.NewSigner([]byte("secret"), "https://api.example.com")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	link := signer.URL(path, now.Add(time.Hour))
+
+	if !strings.HasPrefix(link, "https://api.example.com"+path+"?") {
+		t.Fatalf("link = %s", link)
+	}
+
+	if err := signer.Verify(path, params(t, link), now); err != nil {
+		t.Fatalf("valid link: %v", err)
+	}
+
+	if err := signer.Verify(path, params(t, link), now.Add(time.Hour)); !errors.Is(err, storage.ErrLinkExpired) {
+		t.Fatalf("expired link: %v", err)
+	}
+
+	if err := signer.Verify("/api/exports/other/download", params(t, link), now); !errors.Is(err, storage.ErrInvalidSignature) {
+		t.Fatalf("other path: %v", err)
+	}
+
+	tampered := params(t, link)
+	tampered.Set("expires", tampered.Get("expires")+"0")
+	if err := signer.Verify(path, tampered, now); !errors.Is(err, storage.ErrInvalidSignature) {
+		t.Fatalf("extended expiry: %v", err)
+	}
+
+	other := storage.NewSigner([]byte("other"), "")
+	if err := other.Verify(path, params(t, link), now); !errors.Is(err, storage.ErrInvalidSignature) {
+		t.Fatalf("other secret: %v", err)
+	}
+
+	if err := signer.Verify(path, url.Values{}, now); !errors.Is(err, storage.ErrInvalidSignature) {
+		t.Fatalf("unsigned: %v", err)
+	}
+}
+
+func TestDisk(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	disk, err := storage.NewDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := disk.Put(ctx, "job.csv", strings.NewReader("a,b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := disk.Open(ctx, "job.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(f)
+	f.Close()
+
+	if string(b) != "a,b\n" {
+		t.Fatalf("contents = %q", b)
+	}
+
+	// only the stored file is left behind, not the upload
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("%d files in storage dir", len(entries))
+	}
+
+	for _, key := range []string{"", "..", "../job.csv", "a/b.csv"} {
+		if err := disk.Put(ctx, key, strings.NewReader("")); !errors.Is(err, storage.ErrInvalidKey) {
+			t.Fatalf("key %q: %v", key, err)
+		}
+	}
+
+	if err := disk.Delete(ctx, "job.csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := disk.Delete(ctx, "job.csv"); err != nil {
+		t.Fatalf("deleting a missing key: %v", err)
+	}
+
+	if _, err := disk.Open(ctx, "job.csv"); err == nil {
+		t.Fatal("deleted file can still be opened")
+	}
+}
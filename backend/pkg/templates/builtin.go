@@ -0,0 +1,177 @@
+package templates
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+)
+
+// Built-in template UUIDs only need to be unique within a template, every
+// instance gets fresh ones through form_repo.CloneWithNewUUIDs
+
+func init() {
+	Register(leadCapture())
+	Register(contact())
+	Register(eventRegistration())
+	Register(quoteRequest())
+}
+
+func intPtr(i int) *int           { return &i }
+func floatPtr(f float64) *float64 { return &f }
+func boolPtr(b bool) *bool        { return &b }
+func strPtr(s string) *string     { return &s }
+
+func options(values ...string) []form_repo.Option {
+	opts := make([]form_repo.Option, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		opts = append(opts, form_repo.Option{
+			UUID:  values[i] + "-option",
+			Value: values[i],
+			Label: values[i+1],
+		})
+	}
+	return opts
+}
+
+func leadCapture() *Template {
+	return &Template{
+		Key:         "lead_capture",
+		Name:        "Lead capture",
+		Description: "Collect contact details and qualify interest in a single step",
+		FormData: form_repo.FormData{
+			Steps: []form_repo.Step{
+				{
+					UUID:        "lead-details",
+					Title:       "Your details",
+					Description: "Tell us how to reach you",
+					Fields: []form_repo.Field{
+						{UUID: "lead-full-name", Type: "text", Name: "full_name", Label: "Full name", Required: true, Validation: &form_repo.Validation{MaxLength: intPtr(120)}},
+						{UUID: "lead-email", Type: "email", Name: "email", Label: "Email", Required: true},
+						{UUID: "lead-phone", Type: "text", Name: "phone", Label: "Phone number", Validation: &form_repo.Validation{Matches: strPtr(`^\+?[0-9 ()-]{7,20}$`)}},
+						{UUID: "lead-company", Type: "text", Name: "company", Label: "Company"},
+						{UUID: "lead-interest", Type: "select", Name: "interest", Label: "What are you interested in?", Required: true, Options: options(
+							"product", "Our product",
+							"pricing", "Pricing",
+							"partnership", "Partnerships",
+							"other", "Something else",
+						)},
+						{UUID: "lead-consent", Type: "checkbox", Name: "consent", Label: "I agree to be contacted about my enquiry", Required: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func contact() *Template {
+	return &Template{
+		Key:         "contact",
+		Name:        "Contact us",
+		Description: "A simple contact form with a message box",
+		FormData: form_repo.FormData{
+			Steps: []form_repo.Step{
+				{
+					UUID:  "contact-step",
+					Title: "Get in touch",
+					Fields: []form_repo.Field{
+						{UUID: "contact-full-name", Type: "text", Name: "full_name", Label: "Name", Required: true},
+						{UUID: "contact-email", Type: "email", Name: "email", Label: "Email", Required: true},
+						{UUID: "contact-subject", Type: "text", Name: "subject", Label: "Subject", Validation: &form_repo.Validation{MaxLength: intPtr(150)}},
+						{UUID: "contact-message", Type: "textarea", Name: "message", Label: "Message", Required: true, Validation: &form_repo.Validation{MinLength: intPtr(10), MaxLength: intPtr(2000)}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func eventRegistration() *Template {
+	return &Template{
+		Key:         "event_registration",
+		Name:        "Event registration",
+		Description: "Register attendees, their sessions and dietary requirements",
+		FormData: form_repo.FormData{
+			Steps: []form_repo.Step{
+				{
+					UUID:  "event-attendee",
+					Title: "Attendee",
+					Fields: []form_repo.Field{
+						{UUID: "event-full-name", Type: "text", Name: "full_name", Label: "Full name", Required: true},
+						{UUID: "event-email", Type: "email", Name: "email", Label: "Email", Required: true},
+						{UUID: "event-ticket", Type: "radio", Name: "ticket", Label: "Ticket type", Required: true, Options: options(
+							"standard", "Standard",
+							"vip", "VIP",
+							"student", "Student",
+						)},
+						{UUID: "event-guests", Type: "number", Name: "guests", Label: "Additional guests", DefaultValue: "0", Validation: &form_repo.Validation{Min: floatPtr(0), Max: floatPtr(5), Integer: boolPtr(true)}},
+					},
+				},
+				{
+					UUID:  "event-sessions",
+					Title: "Sessions",
+					Fields: []form_repo.Field{
+						{UUID: "event-session-choice", Type: "checkbox", Name: "sessions", Label: "Which sessions will you attend?", Required: true, Options: options(
+							"morning", "Morning keynote",
+							"workshop", "Afternoon workshop",
+							"networking", "Evening networking",
+						), Validation: &form_repo.Validation{MinItems: intPtr(1)}},
+						{UUID: "event-dietary", Type: "select", Name: "dietary", Label: "Dietary requirements", Options: options(
+							"none", "None",
+							"vegetarian", "Vegetarian",
+							"vegan", "Vegan",
+							"other", "Other",
+						)},
+						{
+							UUID: "event-dietary-other", Type: "text", Name: "dietary_other", Label: "Please describe your dietary requirements", Required: true,
+							Condition: &form_repo.Condition{Operator: "AND", Conditions: []form_repo.CondRule{
+								{Type: "field", UUID: "event-dietary", Operator: "equals", Value: "other"},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func quoteRequest() *Template {
+	return &Template{
+		Key:         "quote_request",
+		Name:        "Quote request",
+		Description: "Gather project scope, budget and timeline before quoting",
+		FormData: form_repo.FormData{
+			Steps: []form_repo.Step{
+				{
+					UUID:  "quote-contact",
+					Title: "About you",
+					Fields: []form_repo.Field{
+						{UUID: "quote-full-name", Type: "text", Name: "full_name", Label: "Full name", Required: true},
+						{UUID: "quote-email", Type: "email", Name: "email", Label: "Email", Required: true},
+						{UUID: "quote-company", Type: "text", Name: "company", Label: "Company"},
+					},
+				},
+				{
+					UUID:  "quote-project",
+					Title: "Your project",
+					Fields: []form_repo.Field{
+						{UUID: "quote-service", Type: "select", Name: "service", Label: "Service", Required: true, Options: options(
+							"design", "Design",
+							"development", "Development",
+							"consulting", "Consulting",
+						)},
+						{UUID: "quote-details", Type: "textarea", Name: "details", Label: "Project details", Required: true, Validation: &form_repo.Validation{MinLength: intPtr(20)}},
+						{UUID: "quote-budget", Type: "number", Name: "budget", Label: "Budget", Validation: &form_repo.Validation{Positive: boolPtr(true)}},
+						{UUID: "quote-has-deadline", Type: "radio", Name: "has_deadline", Label: "Do you have a deadline?", Required: true, Options: options(
+							"yes", "Yes",
+							"no", "No",
+						)},
+						{
+							UUID: "quote-deadline", Type: "date", Name: "deadline", Label: "Deadline", Required: true,
+							Condition: &form_repo.Condition{Operator: "AND", Conditions: []form_repo.CondRule{
+								{Type: "field", UUID: "quote-has-deadline", Operator: "equals", Value: "yes"},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package templates
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+)
+
+// Template is a starting point for a new form. Built-in templates are
+// registered in code, a user's private templates are stored in form_templates
+// and keyed by their uuid.
+type Template struct {
+	Key         string             `json:"key"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	BuiltIn     bool               `json:"built_in"`
+	FormData    form_repo.FormData `json:"form_data"`
+}
+
+var registry = map[string]*Template{}
+
+// keys in registration order so listings are stable
+var order = []string{}
+
+// Register adds a built-in template, it panics on a duplicate key since that
+// can only be a programming error
+func Register(t *Template) {
+	if _, exists := registry[t.Key]; exists {
+		panic("templates: duplicate key " + t.Key)
+	}
+	t.BuiltIn = true
+	registry[t.Key] = t
+	order = append(order, t.Key)
+}
+
+// Get returns the built-in template for key, or nil
+func Get(key string) *Template {
+	return registry[key]
+}
+
+// List returns every built-in template in registration order
+func List() []*Template {
+	list := make([]*Template, 0, len(order))
+	for _, key := range order {
+		list = append(list, registry[key])
+	}
+	return list
+}
@@ -0,0 +1,16 @@
+package templates_test
+
+import (
+	"formaura/pkg/templates"
+	"formaura/pkg/validate"
+	"testing"
+)
+
+func TestBuiltinTemplatesLint(t *testing.T) {
+	for _, tmpl := range templates.List() {
+		issues := validate.LintFormData(&tmpl.FormData)
+		if len(issues) > 0 {
+			t.Errorf("template %s has lint issues: %+v", tmpl.Key, issues)
+		}
+	}
+}
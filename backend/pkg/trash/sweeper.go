@@ -0,0 +1,85 @@
+package trash
+
+import (
+	"context"
+	form_repo "formaura/pkg/repositories/form"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultRetentionDays = 30
+
+const sweepInterval = time.Hour
+
+// Retention is how long a form stays in the trash before it is purged, set
+// with TRASH_RETENTION_DAYS
+func Retention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Sweeper periodically purges forms that have been in the trash for longer
+// than the retention period
+type Sweeper struct {
+	repo      form_repo.Repository
+	retention time.Duration
+	interval  time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewSweeper(repo form_repo.Repository, retention time.Duration) *Sweeper {
+	return &Sweeper{
+		repo:      repo,
+		retention: retention,
+		interval:  sweepInterval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (s *Sweeper) Start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.sweep()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sweep()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the sweep loop and waits for an in flight sweep to finish
+func (s *Sweeper) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Sweeper) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	purged, err := s.repo.PurgeDeletedBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		log.Printf("Trash sweep failed: %v", err)
+		return
+	}
+
+	if purged > 0 {
+		log.Printf("🧹 Purged %d form(s) from the trash", purged)
+	}
+}
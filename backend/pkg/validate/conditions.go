@@ -0,0 +1,317 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	form_repo "formaura/pkg/repositories/form"
+)
+
+// Condition operators, the frontend writes these in snake_case so both
+// spellings are accepted, see normalizeOperator
+const (
+	OpEquals      = "equals"
+	OpNotEquals   = "notEquals"
+	OpContains    = "contains"
+	OpGreaterThan = "greaterThan"
+	OpLessThan    = "lessThan"
+	OpIsEmpty     = "isEmpty"
+	OpIsNotEmpty  = "isNotEmpty"
+	OpIsCompleted = "isCompleted"
+)
+
+const (
+	CondAnd = "AND"
+	CondOr  = "OR"
+)
+
+const (
+	RuleField = "field"
+	RuleStep  = "step"
+)
+
+var snakeOperators = map[string]string{
+	"not_equals":   OpNotEquals,
+	"greater_than": OpGreaterThan,
+	"less_than":    OpLessThan,
+	"is_empty":     OpIsEmpty,
+	"is_not_empty": OpIsNotEmpty,
+	"is_completed": OpIsCompleted,
+}
+
+var ValidOperators = []string{
+	OpEquals, OpNotEquals, OpContains, OpGreaterThan, OpLessThan, OpIsEmpty, OpIsNotEmpty, OpIsCompleted,
+}
+
+func normalizeOperator(op string) string {
+	if camel, ok := snakeOperators[op]; ok {
+		return camel
+	}
+	return op
+}
+
+// IsValidOperator reports whether a CondRule operator is understood, in either spelling
+func IsValidOperator(op string) bool {
+	op = normalizeOperator(op)
+	for _, valid := range ValidOperators {
+		if op == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Visibility holds which steps and fields are shown for a given answer set
+type Visibility struct {
+	Steps  map[string]bool
+	Fields map[string]bool
+}
+
+func (v *Visibility) StepVisible(uuid string) bool {
+	return v.Steps[uuid]
+}
+
+func (v *Visibility) FieldVisible(uuid string) bool {
+	return v.Fields[uuid]
+}
+
+type visibilityEvaluator struct {
+	*Visibility
+	steps   map[string]*form_repo.Step
+	answers map[string]any
+}
+
+// EvaluateVisibility walks the form in order and decides which steps and
+// fields are visible. A field is only visible when its step is, and a hidden
+// field is treated as empty by any rule that references it.
+func EvaluateVisibility(formData *form_repo.FormData, answers map[string]any) *Visibility {
+	e := &visibilityEvaluator{
+		Visibility: &Visibility{
+			Steps:  map[string]bool{},
+			Fields: map[string]bool{},
+		},
+		steps:   map[string]*form_repo.Step{},
+		answers: answers,
+	}
+
+	for i := range formData.Steps {
+		e.steps[formData.Steps[i].UUID] = &formData.Steps[i]
+	}
+
+	for _, step := range formData.Steps {
+		stepVisible := e.evaluate(step.Condition)
+		e.Steps[step.UUID] = stepVisible
+
+		for _, field := range step.Fields {
+			e.Fields[field.UUID] = stepVisible && e.evaluate(field.Condition)
+		}
+	}
+
+	return e.Visibility
+}
+
+func (e *visibilityEvaluator) evaluate(cond *form_repo.Condition) bool {
+	if cond == nil || len(cond.Conditions) == 0 {
+		return true
+	}
+
+	isOr := strings.EqualFold(cond.Operator, CondOr)
+
+	for _, rule := range cond.Conditions {
+		passed := e.evaluateRule(&rule)
+
+		if isOr && passed {
+			return true
+		}
+		if !isOr && !passed {
+			return false
+		}
+	}
+
+	return !isOr
+}
+
+func (e *visibilityEvaluator) evaluateRule(rule *form_repo.CondRule) bool {
+	op := normalizeOperator(rule.Operator)
+
+	if rule.Type == RuleStep {
+		return e.evaluateStepRule(rule.UUID, op)
+	}
+
+	return EvaluateOperator(e.answer(rule.UUID), op, rule.Value)
+}
+
+// answer returns the value for a field, hidden fields always read as empty
+func (e *visibilityEvaluator) answer(uuid string) any {
+	if visible, evaluated := e.Fields[uuid]; evaluated && !visible {
+		return nil
+	}
+	return e.answers[uuid]
+}
+
+func (e *visibilityEvaluator) evaluateStepRule(uuid, op string) bool {
+	step, ok := e.steps[uuid]
+	// steps later in the form have not been evaluated yet and count as hidden
+	if !ok || !e.Steps[uuid] {
+		return op == OpIsEmpty
+	}
+
+	answered, completed := 0, true
+	for _, field := range step.Fields {
+		if !e.Fields[field.UUID] {
+			continue
+		}
+		if IsEmptyAnswer(e.answers[field.UUID]) {
+			if field.Required {
+				completed = false
+			}
+			continue
+		}
+		answered++
+	}
+
+	switch op {
+	case OpIsCompleted:
+		return completed
+	case OpIsEmpty:
+		return answered == 0
+	case OpIsNotEmpty:
+		return answered > 0
+	}
+
+	return false
+}
+
+// EvaluateOperator compares an answer against a rule value. Unknown operators
+// fail, so a typo hides the step or field rather than silently showing it,
+// and LintFormData reports them when the form is saved.
+func EvaluateOperator(value any, op string, target any) bool {
+	switch normalizeOperator(op) {
+	case OpEquals:
+		return looseEquals(value, target)
+	case OpNotEquals:
+		return !looseEquals(value, target)
+	case OpContains:
+		return contains(value, target)
+	case OpGreaterThan:
+		cmp, ok := compare(value, target)
+		return ok && cmp > 0
+	case OpLessThan:
+		cmp, ok := compare(value, target)
+		return ok && cmp < 0
+	case OpIsEmpty:
+		return IsEmptyAnswer(value)
+	case OpIsNotEmpty, OpIsCompleted:
+		return !IsEmptyAnswer(value)
+	}
+	return false
+}
+
+// looseEquals compares numbers by value and everything else as text. A
+// checkbox answer equals a list with the same options in any order, or a
+// single value when that is the only option ticked.
+func looseEquals(a, b any) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	listA, aIsList := asList(a)
+	listB, bIsList := asList(b)
+
+	switch {
+	case aIsList && bIsList:
+		return sameItems(listA, listB)
+	case aIsList:
+		return len(listA) == 1 && looseEquals(listA[0], b)
+	case bIsList:
+		return len(listB) == 1 && looseEquals(a, listB[0])
+	}
+
+	if x, ok := ToNumber(a); ok {
+		if y, ok := ToNumber(b); ok {
+			return x == y
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// asList returns a checkbox answer or rule value as a list of items
+func asList(v any) ([]any, bool) {
+	switch list := v.(type) {
+	case []any:
+		return list, true
+	case []string:
+		items := make([]any, len(list))
+		for i, item := range list {
+			items[i] = item
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+func sameItems(a, b []any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	matched := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for i, y := range b {
+			if !matched[i] && looseEquals(x, y) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// contains reports whether a text answer contains the target, or a checkbox
+// answer has the target ticked
+func contains(value, target any) bool {
+	if list, ok := asList(value); ok {
+		for _, item := range list {
+			if looseEquals(item, target) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if v, ok := value.(string); ok {
+		return strings.Contains(v, fmt.Sprint(target))
+	}
+	return false
+}
+
+// compare orders two values as numbers, falling back to dates
+func compare(a, b any) (int, bool) {
+	if x, ok := ToNumber(a); ok {
+		if y, ok := ToNumber(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		x, xok := ParseDate(as)
+		y, yok := ParseDate(bs)
+		if xok && yok {
+			return x.Compare(y), true
+		}
+	}
+
+	return 0, false
+}
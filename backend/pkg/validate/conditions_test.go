@@ -0,0 +1,117 @@
+package validate_test
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/validate"
+	"testing"
+)
+
+func conditionalFormData() *form_repo.FormData {
+	return &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{
+				UUID: "step-1",
+				Fields: []form_repo.Field{
+					{UUID: "has-company", Type: validate.FieldRadio, Required: true, Options: []form_repo.Option{{Value: "yes"}, {Value: "no"}}},
+					{
+						UUID:     "company",
+						Type:     validate.FieldText,
+						Required: true,
+						Condition: &form_repo.Condition{
+							Operator:   validate.CondAnd,
+							Conditions: []form_repo.CondRule{{Type: validate.RuleField, UUID: "has-company", Operator: "equals", Value: "yes"}},
+						},
+					},
+				},
+			},
+			{
+				UUID: "step-2",
+				Condition: &form_repo.Condition{
+					Operator: validate.CondOr,
+					Conditions: []form_repo.CondRule{
+						{Type: validate.RuleField, UUID: "company", Operator: "is_not_empty"},
+						{Type: validate.RuleField, UUID: "budget", Operator: "greater_than", Value: 1000},
+					},
+				},
+				Fields: []form_repo.Field{
+					{UUID: "employees", Type: validate.FieldNumber, Required: true},
+				},
+			},
+		},
+	}
+}
+
+func TestEvaluateVisibility(t *testing.T) {
+	v := validate.EvaluateVisibility(conditionalFormData(), map[string]any{
+		"has-company": "no",
+		"company":     "Formaura",
+	})
+
+	if v.FieldVisible("company") {
+		t.Error("expected company field to be hidden")
+	}
+
+	// company is hidden so its stale answer must not reveal step 2
+	if v.StepVisible("step-2") {
+		t.Error("expected step-2 to be hidden")
+	}
+
+	v = validate.EvaluateVisibility(conditionalFormData(), map[string]any{
+		"has-company": "yes",
+		"company":     "Formaura",
+	})
+
+	if !v.FieldVisible("company") || !v.StepVisible("step-2") || !v.FieldVisible("employees") {
+		t.Error("expected company, step-2 and employees to be visible")
+	}
+}
+
+func TestValidateSubmission_SkipsHiddenFields(t *testing.T) {
+	clean, errs := validate.ValidateSubmission(conditionalFormData(), map[string]any{
+		"has-company": "no",
+		"company":     "Formaura",
+	})
+
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	if _, ok := clean["company"]; ok {
+		t.Error("expected hidden company answer to be dropped")
+	}
+}
+
+func TestEvaluateOperator(t *testing.T) {
+	ticked := []any{"web", "seo"}
+
+	tests := []struct {
+		name   string
+		value  any
+		op     string
+		target any
+		want   bool
+	}{
+		{"unknown operator", "yes", "equalz", "yes", false},
+		{"unknown operator on empty", nil, "isNotEmty", nil, false},
+		{"equals number as text", "10", "equals", 10, true},
+		{"not_equals", "a", "not_equals", "b", true},
+		{"checkbox contains ticked", ticked, "contains", "seo", true},
+		{"checkbox contains unticked", ticked, "contains", "print", false},
+		{"checkbox equals same options", ticked, "equals", []any{"seo", "web"}, true},
+		{"checkbox equals other options", ticked, "equals", []any{"seo", "print"}, false},
+		{"checkbox equals single ticked", []any{"web"}, "equals", "web", true},
+		{"checkbox equals one of several", ticked, "equals", "web", false},
+		{"checkbox not_equals", ticked, "not_equals", []any{"web"}, true},
+		{"string slice contains", []string{"web"}, "contains", "web", true},
+		{"text contains", "Formaura Ltd", "contains", "Ltd", true},
+		{"greater_than", "2500", "greater_than", 1000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validate.EvaluateOperator(tt.value, tt.op, tt.target); got != tt.want {
+				t.Errorf("EvaluateOperator(%v, %q, %v) = %v, want %v", tt.value, tt.op, tt.target, got, tt.want)
+			}
+		})
+	}
+}
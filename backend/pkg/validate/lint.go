@@ -0,0 +1,449 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	form_repo "formaura/pkg/repositories/form"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Lint issue codes
+const (
+	LintMissingUUID        = "missing_uuid"
+	LintDuplicateUUID      = "duplicate_uuid"
+	LintDuplicateName      = "duplicate_name"
+	LintDuplicateTitle     = "duplicate_title"
+	LintMissingName        = "missing_name"
+	LintUnknownType        = "unknown_type"
+	LintMissingOptions     = "missing_options"
+	LintDuplicateOption    = "duplicate_option"
+	LintInvalidRegex       = "invalid_regex"
+	LintInvalidDate        = "invalid_date"
+	LintNegativeLength     = "negative_length"
+	LintContradiction      = "contradictory_validation"
+	LintInapplicable       = "inapplicable_validation"
+	LintInvalidCondOp      = "invalid_condition_operator"
+	LintInvalidRuleType    = "invalid_rule_type"
+	LintInvalidRuleOp      = "invalid_rule_operator"
+	LintUnknownTarget      = "unknown_condition_target"
+	LintForwardReference   = "forward_condition_reference"
+	LintCircularCondition  = "circular_condition"
+	LintMissingRuleValue   = "missing_rule_value"
+	LintInvalidDefault     = "invalid_default_value"
+	LintEmptyConditionList = "empty_condition"
+)
+
+var ValidFieldTypes = []string{
+	FieldText, FieldEmail, FieldTextarea, FieldSelect, FieldRadio, FieldCheckbox, FieldNumber, FieldDate,
+}
+
+var stringFieldTypes = []string{FieldText, FieldEmail, FieldTextarea}
+
+// Issue is a single problem found in a form definition, Path is a JSON path
+// into the submitted form_data
+type Issue struct {
+	Path     string `json:"path"`
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type Issues []Issue
+
+func (i Issues) HasErrors() bool {
+	return slices.ContainsFunc(i, func(issue Issue) bool {
+		return issue.Severity == SeverityError
+	})
+}
+
+// position of a step or field in the form, used to spot forward references
+type lintNode struct {
+	path  string
+	order int
+	step  *form_repo.Step
+	field *form_repo.Field
+}
+
+type linter struct {
+	issues Issues
+	nodes  map[string]*lintNode
+}
+
+func (l *linter) add(severity, path, code, format string, args ...any) {
+	l.issues = append(l.issues, Issue{
+		Path:     path,
+		Code:     code,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// LintFormData checks a form definition for structural problems before it is
+// saved. Issues with SeverityError should block the save, warnings are
+// returned to the builder as hints.
+func LintFormData(formData *form_repo.FormData) Issues {
+	l := &linter{
+		issues: Issues{},
+		nodes:  map[string]*lintNode{},
+	}
+
+	l.indexNodes(formData)
+
+	for i := range formData.Steps {
+		step := &formData.Steps[i]
+		stepPath := fmt.Sprintf("$.steps[%d]", i)
+
+		l.lintCondition(stepPath, step.UUID, step.Condition)
+
+		for j := range step.Fields {
+			field := &step.Fields[j]
+			fieldPath := fmt.Sprintf("%s.fields[%d]", stepPath, j)
+
+			l.lintField(fieldPath, field)
+			l.lintCondition(fieldPath, field.UUID, field.Condition)
+		}
+	}
+
+	l.lintCycles(formData)
+
+	return l.issues
+}
+
+// indexNodes records every step and field by UUID in form order and reports
+// missing or duplicated identifiers along the way
+func (l *linter) indexNodes(formData *form_repo.FormData) {
+	order := 0
+	stepTitles := map[string]string{}
+	fieldNames := map[string]string{}
+
+	register := func(path, uuid string, node *lintNode) {
+		if uuid == "" {
+			l.add(SeverityError, path+".uuid", LintMissingUUID, "UUID is required")
+			return
+		}
+		if existing, ok := l.nodes[uuid]; ok {
+			l.add(SeverityError, path+".uuid", LintDuplicateUUID, "UUID %s is already used at %s", uuid, existing.path)
+			return
+		}
+		l.nodes[uuid] = node
+	}
+
+	for i := range formData.Steps {
+		step := &formData.Steps[i]
+		stepPath := fmt.Sprintf("$.steps[%d]", i)
+
+		register(stepPath, step.UUID, &lintNode{path: stepPath, order: order, step: step})
+		order++
+
+		if title := strings.TrimSpace(step.Title); title != "" {
+			if existing, ok := stepTitles[strings.ToLower(title)]; ok {
+				l.add(SeverityWarning, stepPath+".title", LintDuplicateTitle, "Step title %q is already used at %s", title, existing)
+			} else {
+				stepTitles[strings.ToLower(title)] = stepPath + ".title"
+			}
+		}
+
+		for j := range step.Fields {
+			field := &step.Fields[j]
+			fieldPath := fmt.Sprintf("%s.fields[%d]", stepPath, j)
+
+			register(fieldPath, field.UUID, &lintNode{path: fieldPath, order: order, field: field})
+			order++
+
+			name := strings.TrimSpace(field.Name)
+			if name == "" {
+				l.add(SeverityWarning, fieldPath+".name", LintMissingName, "Field name is empty")
+				continue
+			}
+			if existing, ok := fieldNames[name]; ok {
+				l.add(SeverityError, fieldPath+".name", LintDuplicateName, "Field name %q is already used at %s", name, existing)
+			} else {
+				fieldNames[name] = fieldPath + ".name"
+			}
+		}
+	}
+}
+
+func (l *linter) lintField(path string, field *form_repo.Field) {
+	if !slices.Contains(ValidFieldTypes, field.Type) {
+		l.add(SeverityError, path+".type", LintUnknownType, "Unknown field type %q", field.Type)
+	}
+
+	switch field.Type {
+	case FieldSelect, FieldRadio:
+		if len(field.Options) == 0 {
+			l.add(SeverityError, path+".options", LintMissingOptions, "A %s field needs at least one option", field.Type)
+		}
+	}
+
+	optionValues := map[string]int{}
+	for i, o := range field.Options {
+		optionPath := fmt.Sprintf("%s.options[%d]", path, i)
+		if existing, ok := optionValues[o.Value]; ok {
+			l.add(SeverityError, optionPath+".value", LintDuplicateOption, "Option value %q is already used by option %d", o.Value, existing)
+			continue
+		}
+		optionValues[o.Value] = i
+	}
+
+	if field.DefaultValue != "" && len(field.Options) > 0 && field.Type != FieldCheckbox {
+		if _, ok := optionValues[field.DefaultValue]; !ok {
+			l.add(SeverityWarning, path+".default_value", LintInvalidDefault, "Default value %q is not one of the options", field.DefaultValue)
+		}
+	}
+
+	if field.Validation != nil {
+		l.lintValidation(path+".validation", field.Type, field.Validation)
+	}
+}
+
+func (l *linter) lintValidation(path, fieldType string, v *form_repo.Validation) {
+	isString := slices.Contains(stringFieldTypes, fieldType)
+	isNumber := fieldType == FieldNumber
+	isArray := fieldType == FieldCheckbox
+	isDate := fieldType == FieldDate
+
+	inapplicable := func(key string, set bool, applies bool) {
+		if set && !applies {
+			l.add(SeverityWarning, path+"."+key, LintInapplicable, "%s has no effect on a %s field", key, fieldType)
+		}
+	}
+
+	inapplicable("min_length", v.MinLength != nil, isString)
+	inapplicable("max_length", v.MaxLength != nil, isString)
+	inapplicable("length", v.Length != nil, isString)
+	inapplicable("matches", v.Matches != nil, isString)
+	inapplicable("email", v.Email != nil, isString)
+	inapplicable("url", v.URL != nil, isString)
+	inapplicable("uuid", v.UUID != nil, isString)
+	inapplicable("min", v.Min != nil, isNumber)
+	inapplicable("max", v.Max != nil, isNumber)
+	inapplicable("less_than", v.LessThan != nil, isNumber)
+	inapplicable("more_than", v.MoreThan != nil, isNumber)
+	inapplicable("positive", v.Positive != nil, isNumber)
+	inapplicable("negative", v.Negative != nil, isNumber)
+	inapplicable("integer", v.Integer != nil, isNumber)
+	inapplicable("min_items", v.MinItems != nil, isArray)
+	inapplicable("max_items", v.MaxItems != nil, isArray)
+	inapplicable("min_date", v.MinDate != nil, isDate)
+	inapplicable("max_date", v.MaxDate != nil, isDate)
+
+	lengths := []struct {
+		key string
+		n   *int
+	}{
+		{"min_length", v.MinLength},
+		{"max_length", v.MaxLength},
+		{"length", v.Length},
+		{"min_items", v.MinItems},
+		{"max_items", v.MaxItems},
+	}
+	for _, length := range lengths {
+		if length.n != nil && *length.n < 0 {
+			l.add(SeverityError, path+"."+length.key, LintNegativeLength, "%s cannot be negative", length.key)
+		}
+	}
+
+	if v.Matches != nil {
+		if _, err := regexp.Compile(*v.Matches); err != nil {
+			l.add(SeverityError, path+".matches", LintInvalidRegex, "Invalid regular expression: %v", err)
+		}
+	}
+
+	contradiction := func(key, format string, args ...any) {
+		l.add(SeverityError, path+"."+key, LintContradiction, format, args...)
+	}
+
+	if v.MinLength != nil && v.MaxLength != nil && *v.MinLength > *v.MaxLength {
+		contradiction("min_length", "min_length (%d) is greater than max_length (%d)", *v.MinLength, *v.MaxLength)
+	}
+	if v.Length != nil && v.MinLength != nil && *v.Length < *v.MinLength {
+		contradiction("length", "length (%d) is less than min_length (%d)", *v.Length, *v.MinLength)
+	}
+	if v.Length != nil && v.MaxLength != nil && *v.Length > *v.MaxLength {
+		contradiction("length", "length (%d) is greater than max_length (%d)", *v.Length, *v.MaxLength)
+	}
+	if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+		contradiction("min", "min (%g) is greater than max (%g)", *v.Min, *v.Max)
+	}
+	if v.MoreThan != nil && v.LessThan != nil && *v.MoreThan >= *v.LessThan {
+		contradiction("more_than", "more_than (%g) leaves no value below less_than (%g)", *v.MoreThan, *v.LessThan)
+	}
+	if isTrue(v.Positive) && isTrue(v.Negative) {
+		contradiction("positive", "A value cannot be both positive and negative")
+	}
+	if isTrue(v.Positive) && v.Max != nil && *v.Max <= 0 {
+		contradiction("positive", "positive conflicts with max (%g)", *v.Max)
+	}
+	if isTrue(v.Negative) && v.Min != nil && *v.Min >= 0 {
+		contradiction("negative", "negative conflicts with min (%g)", *v.Min)
+	}
+	if v.MinItems != nil && v.MaxItems != nil && *v.MinItems > *v.MaxItems {
+		contradiction("min_items", "min_items (%d) is greater than max_items (%d)", *v.MinItems, *v.MaxItems)
+	}
+
+	minDate, minOk := l.lintDate(path+".min_date", v.MinDate)
+	maxDate, maxOk := l.lintDate(path+".max_date", v.MaxDate)
+	if minOk && maxOk && minDate.After(maxDate) {
+		contradiction("min_date", "min_date (%s) is after max_date (%s)", *v.MinDate, *v.MaxDate)
+	}
+}
+
+func (l *linter) lintDate(path string, s *string) (t time.Time, ok bool) {
+	if s == nil {
+		return t, false
+	}
+	d, ok := ParseDate(*s)
+	if !ok {
+		l.add(SeverityError, path, LintInvalidDate, "%q is not a valid date", *s)
+		return t, false
+	}
+	return d, true
+}
+
+func (l *linter) lintCondition(path, ownerUUID string, cond *form_repo.Condition) {
+	if cond == nil {
+		return
+	}
+
+	path += ".condition"
+	owner := l.nodes[ownerUUID]
+
+	if !strings.EqualFold(cond.Operator, CondAnd) && !strings.EqualFold(cond.Operator, CondOr) {
+		l.add(SeverityError, path+".operator", LintInvalidCondOp, "Condition operator must be %s or %s", CondAnd, CondOr)
+	}
+
+	if len(cond.Conditions) == 0 {
+		l.add(SeverityWarning, path+".conditions", LintEmptyConditionList, "Condition has no rules and always passes")
+	}
+
+	for i, rule := range cond.Conditions {
+		rulePath := fmt.Sprintf("%s.conditions[%d]", path, i)
+
+		if !IsValidOperator(rule.Operator) {
+			l.add(SeverityError, rulePath+".operator", LintInvalidRuleOp, "Unknown rule operator %q", rule.Operator)
+		}
+
+		switch normalizeOperator(rule.Operator) {
+		case OpEquals, OpNotEquals, OpContains, OpGreaterThan, OpLessThan:
+			if rule.Value == nil {
+				l.add(SeverityWarning, rulePath+".value", LintMissingRuleValue, "Rule operator %q has no value to compare against", rule.Operator)
+			}
+		}
+
+		target, ok := l.nodes[rule.UUID]
+
+		switch rule.Type {
+		case RuleField:
+			ok = ok && target.field != nil
+		case RuleStep:
+			ok = ok && target.step != nil
+		default:
+			l.add(SeverityError, rulePath+".type", LintInvalidRuleType, "Rule type must be %s or %s", RuleField, RuleStep)
+			continue
+		}
+
+		if !ok {
+			l.add(SeverityError, rulePath+".uuid", LintUnknownTarget, "Rule references a %s that does not exist", rule.Type)
+			continue
+		}
+
+		if owner != nil && target != owner && target.order > owner.order {
+			l.add(SeverityWarning, rulePath+".uuid", LintForwardReference, "Rule references %s which comes later in the form", target.path)
+		}
+	}
+}
+
+// lintCycles builds a dependency graph from conditions and reports any loop.
+// A field depends on its step, and a rule on a step depends on every field
+// in it since that is what decides if the step is completed.
+func (l *linter) lintCycles(formData *form_repo.FormData) {
+	edges := map[string][]string{}
+	conditionPaths := map[string]string{}
+
+	addRules := func(uuid, path string, cond *form_repo.Condition) {
+		if cond == nil {
+			return
+		}
+		conditionPaths[uuid] = path + ".condition"
+		for _, rule := range cond.Conditions {
+			target, ok := l.nodes[rule.UUID]
+			if !ok {
+				continue
+			}
+			edges[uuid] = append(edges[uuid], rule.UUID)
+			if target.step != nil {
+				for _, f := range target.step.Fields {
+					edges[uuid] = append(edges[uuid], f.UUID)
+				}
+			}
+		}
+	}
+
+	for i, step := range formData.Steps {
+		stepPath := fmt.Sprintf("$.steps[%d]", i)
+		addRules(step.UUID, stepPath, step.Condition)
+
+		for j, field := range step.Fields {
+			edges[field.UUID] = append(edges[field.UUID], step.UUID)
+			addRules(field.UUID, fmt.Sprintf("%s.fields[%d]", stepPath, j), field.Condition)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := map[string]int{}
+	reported := map[string]bool{}
+
+	var visit func(uuid string, trail []string)
+	visit = func(uuid string, trail []string) {
+		state[uuid] = visiting
+		trail = append(trail, uuid)
+
+		for _, next := range edges[uuid] {
+			switch state[next] {
+			case visiting:
+				// report the loop once, against the first condition taking part in it
+				loop := append(slices.Clone(trail[slices.Index(trail, next):]), next)
+				for _, member := range loop {
+					path, ok := conditionPaths[member]
+					if !ok {
+						continue
+					}
+					if !reported[path] {
+						reported[path] = true
+						l.add(SeverityError, path, LintCircularCondition, "Circular condition: %s", strings.Join(loop, " -> "))
+					}
+					break
+				}
+			case unvisited:
+				visit(next, trail)
+			}
+		}
+
+		state[uuid] = done
+	}
+
+	for _, step := range formData.Steps {
+		if state[step.UUID] == unvisited {
+			visit(step.UUID, nil)
+		}
+		for _, field := range step.Fields {
+			if state[field.UUID] == unvisited {
+				visit(field.UUID, nil)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package validate_test
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/validate"
+	"testing"
+)
+
+func hasIssue(issues validate.Issues, path, code string) bool {
+	for _, issue := range issues {
+		if issue.Path == path && issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanFormData uses every kind of rule the linter checks without breaking any
+func cleanFormData() *form_repo.FormData {
+	return &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{
+				UUID: "step-1",
+				Fields: []form_repo.Field{
+					{UUID: "has-company", Name: "has_company", Type: validate.FieldRadio, Required: true, Options: []form_repo.Option{{Value: "yes"}, {Value: "no"}}},
+					{
+						UUID:       "company",
+						Name:       "company",
+						Type:       validate.FieldText,
+						Validation: &form_repo.Validation{MinLength: intPtr(2), MaxLength: intPtr(80), Matches: strPtr(`^[A-Za-z ]+$`)},
+						Condition: &form_repo.Condition{
+							Operator:   validate.CondAnd,
+							Conditions: []form_repo.CondRule{{Type: validate.RuleField, UUID: "has-company", Operator: "equals", Value: "yes"}},
+						},
+					},
+				},
+			},
+			{
+				UUID: "step-2",
+				Condition: &form_repo.Condition{
+					Operator: validate.CondOr,
+					Conditions: []form_repo.CondRule{
+						{Type: validate.RuleField, UUID: "company", Operator: "is_not_empty"},
+						{Type: validate.RuleField, UUID: "has-company", Operator: "equals", Value: "no"},
+					},
+				},
+				Fields: []form_repo.Field{
+					{UUID: "employees", Name: "employees", Type: validate.FieldNumber, Required: true},
+				},
+			},
+		},
+	}
+}
+
+func TestLintFormData_Clean(t *testing.T) {
+	issues := validate.LintFormData(cleanFormData())
+
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+}
+
+func TestLintFormData_Problems(t *testing.T) {
+	formData := &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{
+				UUID: "step-1",
+				Fields: []form_repo.Field{
+					{UUID: "a", Name: "a", Type: validate.FieldSelect},
+					{UUID: "a", Name: "a", Type: validate.FieldText, Validation: &form_repo.Validation{MinLength: intPtr(5), MaxLength: intPtr(2), Matches: strPtr("(")}},
+					{
+						UUID: "b", Name: "b", Type: validate.FieldText,
+						Condition: &form_repo.Condition{Operator: validate.CondAnd, Conditions: []form_repo.CondRule{
+							{Type: validate.RuleField, UUID: "missing", Operator: "equals", Value: "x"},
+							{Type: validate.RuleField, UUID: "c", Operator: "isNotEmpty"},
+						}},
+					},
+					{
+						UUID: "c", Name: "c", Type: validate.FieldText,
+						Condition: &form_repo.Condition{Operator: validate.CondAnd, Conditions: []form_repo.CondRule{
+							{Type: validate.RuleField, UUID: "b", Operator: "isNotEmpty"},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	issues := validate.LintFormData(formData)
+
+	expected := []struct{ path, code string }{
+		{"$.steps[0].fields[0].options", validate.LintMissingOptions},
+		{"$.steps[0].fields[1].uuid", validate.LintDuplicateUUID},
+		{"$.steps[0].fields[1].name", validate.LintDuplicateName},
+		{"$.steps[0].fields[1].validation.min_length", validate.LintContradiction},
+		{"$.steps[0].fields[1].validation.matches", validate.LintInvalidRegex},
+		{"$.steps[0].fields[2].condition.conditions[0].uuid", validate.LintUnknownTarget},
+		{"$.steps[0].fields[2].condition.conditions[1].uuid", validate.LintForwardReference},
+		{"$.steps[0].fields[2].condition", validate.LintCircularCondition},
+	}
+
+	for _, e := range expected {
+		if !hasIssue(issues, e.path, e.code) {
+			t.Errorf("expected %s at %s, got %+v", e.code, e.path, issues)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package validate
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	form_repo "formaura/pkg/repositories/form"
+)
+
+// Field types understood by the submission validator, these mirror the
+// InputType union used by the frontend builder
+const (
+	FieldText     = "text"
+	FieldEmail    = "email"
+	FieldTextarea = "textarea"
+	FieldSelect   = "select"
+	FieldRadio    = "radio"
+	FieldCheckbox = "checkbox"
+	FieldNumber   = "number"
+	FieldDate     = "date"
+)
+
+// Error codes returned per field, named after the form_repo.Validation json keys
+const (
+	ErrRequired  = "required"
+	ErrType      = "invalid_type"
+	ErrOption    = "invalid_option"
+	ErrMinLength = "min_length"
+	ErrMaxLength = "max_length"
+	ErrLength    = "length"
+	ErrMatches   = "matches"
+	ErrEmail     = "email"
+	ErrURL       = "url"
+	ErrUUID      = "uuid"
+	ErrMin       = "min"
+	ErrMax       = "max"
+	ErrLessThan  = "less_than"
+	ErrMoreThan  = "more_than"
+	ErrPositive  = "positive"
+	ErrNegative  = "negative"
+	ErrInteger   = "integer"
+	ErrMinItems  = "min_items"
+	ErrMaxItems  = "max_items"
+	ErrMinDate   = "min_date"
+	ErrMaxDate   = "max_date"
+)
+
+// FieldErrors maps a field UUID to the error codes raised for its value
+type FieldErrors map[string][]string
+
+func (e FieldErrors) add(uuid, code string) {
+	e[uuid] = append(e[uuid], code)
+}
+
+// DateLayouts are the formats accepted for date answers and min/max date rules
+var DateLayouts = []string{"2006-01-02", time.RFC3339}
+
+// ValidateSubmission checks every answer against the field it belongs to.
+// Answers are keyed by field UUID, anything that does not belong to a visible
+// field in the form is dropped from the returned answers.
+func ValidateSubmission(formData *form_repo.FormData, answers map[string]any) (map[string]any, FieldErrors) {
+	clean := map[string]any{}
+	errs := FieldErrors{}
+
+	visibility := EvaluateVisibility(formData, answers)
+
+	for _, step := range formData.Steps {
+		if !visibility.StepVisible(step.UUID) {
+			continue
+		}
+
+		for _, field := range step.Fields {
+			if !visibility.FieldVisible(field.UUID) {
+				continue
+			}
+
+			value, ok := answers[field.UUID]
+
+			if IsEmptyAnswer(value) {
+				if field.Required {
+					errs.add(field.UUID, ErrRequired)
+				}
+				continue
+			}
+
+			if ok {
+				clean[field.UUID] = value
+			}
+
+			validateField(&field, value, errs)
+		}
+	}
+
+	return clean, errs
+}
+
+// IsEmptyAnswer reports whether an answer should be treated as not filled in
+func IsEmptyAnswer(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case bool:
+		return !v
+	}
+	return false
+}
+
+func validateField(field *form_repo.Field, value any, errs FieldErrors) {
+	switch field.Type {
+	case FieldNumber:
+		n, ok := ToNumber(value)
+		if !ok {
+			errs.add(field.UUID, ErrType)
+			return
+		}
+		validateNumber(field, n, errs)
+
+	case FieldDate:
+		s, ok := value.(string)
+		if !ok {
+			errs.add(field.UUID, ErrType)
+			return
+		}
+		d, ok := ParseDate(s)
+		if !ok {
+			errs.add(field.UUID, ErrType)
+			return
+		}
+		validateDate(field, d, errs)
+
+	case FieldCheckbox:
+		// a checkbox without options is a single tick box, eg. accept terms
+		if len(field.Options) == 0 {
+			if _, ok := value.(bool); !ok {
+				errs.add(field.UUID, ErrType)
+			}
+			return
+		}
+		items, ok := ToStringSlice(value)
+		if !ok {
+			errs.add(field.UUID, ErrType)
+			return
+		}
+		for _, item := range items {
+			if !hasOption(field, item) {
+				errs.add(field.UUID, ErrOption)
+				break
+			}
+		}
+		validateItems(field, len(items), errs)
+
+	case FieldSelect, FieldRadio:
+		s, ok := value.(string)
+		if !ok {
+			errs.add(field.UUID, ErrType)
+			return
+		}
+		if !hasOption(field, s) {
+			errs.add(field.UUID, ErrOption)
+		}
+
+	default:
+		s, ok := value.(string)
+		if !ok {
+			errs.add(field.UUID, ErrType)
+			return
+		}
+		if field.Type == FieldEmail && !IsEmail(s) {
+			errs.add(field.UUID, ErrEmail)
+		}
+		validateString(field, s, errs)
+	}
+}
+
+func validateString(field *form_repo.Field, s string, errs FieldErrors) {
+	v := field.Validation
+	if v == nil {
+		return
+	}
+
+	length := len([]rune(s))
+
+	if v.MinLength != nil && length < *v.MinLength {
+		errs.add(field.UUID, ErrMinLength)
+	}
+	if v.MaxLength != nil && length > *v.MaxLength {
+		errs.add(field.UUID, ErrMaxLength)
+	}
+	if v.Length != nil && length != *v.Length {
+		errs.add(field.UUID, ErrLength)
+	}
+	if v.Matches != nil {
+		re, err := regexp.Compile(*v.Matches)
+		// an uncompilable pattern is a builder problem, not the submitter's
+		if err == nil && !re.MatchString(s) {
+			errs.add(field.UUID, ErrMatches)
+		}
+	}
+	if isTrue(v.Email) && !IsEmail(s) {
+		errs.add(field.UUID, ErrEmail)
+	}
+	if isTrue(v.URL) && !IsURL(s) {
+		errs.add(field.UUID, ErrURL)
+	}
+	if isTrue(v.UUID) && !ValidateUUID(s) {
+		errs.add(field.UUID, ErrUUID)
+	}
+}
+
+func validateNumber(field *form_repo.Field, n float64, errs FieldErrors) {
+	v := field.Validation
+	if v == nil {
+		return
+	}
+
+	if v.Min != nil && n < *v.Min {
+		errs.add(field.UUID, ErrMin)
+	}
+	if v.Max != nil && n > *v.Max {
+		errs.add(field.UUID, ErrMax)
+	}
+	if v.LessThan != nil && n >= *v.LessThan {
+		errs.add(field.UUID, ErrLessThan)
+	}
+	if v.MoreThan != nil && n <= *v.MoreThan {
+		errs.add(field.UUID, ErrMoreThan)
+	}
+	if isTrue(v.Positive) && n <= 0 {
+		errs.add(field.UUID, ErrPositive)
+	}
+	if isTrue(v.Negative) && n >= 0 {
+		errs.add(field.UUID, ErrNegative)
+	}
+	if isTrue(v.Integer) && n != math.Trunc(n) {
+		errs.add(field.UUID, ErrInteger)
+	}
+}
+
+func validateItems(field *form_repo.Field, count int, errs FieldErrors) {
+	v := field.Validation
+	if v == nil {
+		return
+	}
+
+	if v.MinItems != nil && count < *v.MinItems {
+		errs.add(field.UUID, ErrMinItems)
+	}
+	if v.MaxItems != nil && count > *v.MaxItems {
+		errs.add(field.UUID, ErrMaxItems)
+	}
+}
+
+func validateDate(field *form_repo.Field, d time.Time, errs FieldErrors) {
+	v := field.Validation
+	if v == nil {
+		return
+	}
+
+	if v.MinDate != nil {
+		if min, ok := ParseDate(*v.MinDate); ok && d.Before(min) {
+			errs.add(field.UUID, ErrMinDate)
+		}
+	}
+	if v.MaxDate != nil {
+		if max, ok := ParseDate(*v.MaxDate); ok && d.After(max) {
+			errs.add(field.UUID, ErrMaxDate)
+		}
+	}
+}
+
+func hasOption(field *form_repo.Field, value string) bool {
+	for _, o := range field.Options {
+		if o.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// ToNumber accepts json numbers as well as numeric strings from text inputs
+func ToNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// ToStringSlice converts a decoded json array into a slice of strings
+func ToStringSlice(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, s)
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+func ParseDate(s string) (time.Time, bool) {
+	for _, layout := range DateLayouts {
+		if d, err := time.Parse(layout, s); err == nil {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func IsURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
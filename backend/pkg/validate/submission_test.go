@@ -0,0 +1,88 @@
+package validate_test
+
+import (
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/validate"
+	"slices"
+	"testing"
+)
+
+func intPtr(i int) *int           { return &i }
+func floatPtr(f float64) *float64 { return &f }
+func boolPtr(b bool) *bool        { return &b }
+func strPtr(s string) *string     { return &s }
+
+func testFormData() *form_repo.FormData {
+	return &form_repo.FormData{
+		Steps: []form_repo.Step{
+			{
+				UUID: "step-1",
+				Fields: []form_repo.Field{
+					{UUID: "name", Type: validate.FieldText, Required: true, Validation: &form_repo.Validation{MinLength: intPtr(2), MaxLength: intPtr(10)}},
+					{UUID: "email", Type: validate.FieldEmail, Required: true},
+					{UUID: "code", Type: validate.FieldText, Validation: &form_repo.Validation{Matches: strPtr(`^[A-Z]{3}$`)}},
+					{UUID: "age", Type: validate.FieldNumber, Validation: &form_repo.Validation{Min: floatPtr(18), Integer: boolPtr(true)}},
+					{UUID: "colour", Type: validate.FieldSelect, Options: []form_repo.Option{{Value: "red"}, {Value: "blue"}}},
+					{UUID: "tags", Type: validate.FieldCheckbox, Options: []form_repo.Option{{Value: "a"}, {Value: "b"}}, Validation: &form_repo.Validation{MaxItems: intPtr(1)}},
+					{UUID: "start", Type: validate.FieldDate, Validation: &form_repo.Validation{MinDate: strPtr("2025-01-01")}},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateSubmission_Valid(t *testing.T) {
+	answers := map[string]any{
+		"name":    "Kez",
+		"email":   "kez@example.com",
+		"code":    "ABC",
+		"age":     float64(30),
+		"colour":  "red",
+		"tags":    []any{"a"},
+		"start":   "2025-06-01",
+		"unknown": "dropped",
+	}
+
+	clean, errs := validate.ValidateSubmission(testFormData(), answers)
+
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	if _, ok := clean["unknown"]; ok {
+		t.Error("expected unknown answer to be dropped")
+	}
+
+	if len(clean) != 7 {
+		t.Errorf("expected 7 answers, got %d", len(clean))
+	}
+}
+
+func TestValidateSubmission_Errors(t *testing.T) {
+	answers := map[string]any{
+		"name":   "K",
+		"code":   "abc",
+		"age":    17.5,
+		"colour": "green",
+		"tags":   []any{"a", "b"},
+		"start":  "2024-12-31",
+	}
+
+	_, errs := validate.ValidateSubmission(testFormData(), answers)
+
+	expected := map[string][]string{
+		"name":   {validate.ErrMinLength},
+		"email":  {validate.ErrRequired},
+		"code":   {validate.ErrMatches},
+		"age":    {validate.ErrMin, validate.ErrInteger},
+		"colour": {validate.ErrOption},
+		"tags":   {validate.ErrMaxItems},
+		"start":  {validate.ErrMinDate},
+	}
+
+	for uuid, codes := range expected {
+		if !slices.Equal(errs[uuid], codes) {
+			t.Errorf("field %s: expected %v, got %v", uuid, codes, errs[uuid])
+		}
+	}
+}
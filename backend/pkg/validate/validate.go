@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"net/mail"
 	"regexp"
 	"slices"
 
@@ -29,6 +30,15 @@ func ValidateUUID(uuid string) bool {
 	return testUUID.MatchString(uuid)
 }
 
+func IsEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
 func IsValidStatus(status string) bool {
 	return slices.Contains(form_repo.ValidStatuses, status)
 }
+
+func IsValidSearchLanguage(language string) bool {
+	return slices.Contains(form_repo.SearchLanguages, language)
+}
@@ -0,0 +1,141 @@
+package views
+
+import (
+	"context"
+	form_repo "formaura/pkg/repositories/form"
+	"log"
+	"sync"
+	"time"
+)
+
+const flushInterval = 10 * time.Second
+
+// Store persists buffered view counts, keyed by form and day, and daily visitors
+type Store interface {
+	AddViews(ctx context.Context, counts map[form_repo.ViewKey]int, visitors []form_repo.Visitor) error
+}
+
+// Counter buffers form views in memory and writes them in one batched
+// statement per interval, so a public form load never waits on or fails
+// because of the views write.
+//
+// Visitors are hashed with a random salt for the current day that is dropped
+// when the day changes. A restart also starts a new salt, so visitors seen
+// earlier that day are counted as unique again, and each api instance counts
+// its own unique visitors.
+type Counter struct {
+	store    Store
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	counts   map[form_repo.ViewKey]int
+	visitors map[form_repo.Visitor]struct{}
+	saltDay  time.Time
+	salt     []byte
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewCounter(store Store) *Counter {
+	return &Counter{
+		store:    store,
+		interval: flushInterval,
+		now:      time.Now,
+		counts:   map[form_repo.ViewKey]int{},
+		visitors: map[form_repo.Visitor]struct{}{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Add records a view of a form, bots are ignored. The visitor is kept only as
+// a daily salted hash of ip and user agent, see VisitorHash.
+func (c *Counter) Add(formId int, ip, userAgent string) {
+	if IsBot(userAgent) {
+		return
+	}
+
+	day := Day(c.now())
+
+	c.mu.Lock()
+	visitor := form_repo.Visitor{
+		FormID: formId,
+		Day:    day,
+		Hash:   VisitorHash(c.daySalt(day), formId, ip, userAgent),
+	}
+	c.counts[form_repo.ViewKey{FormID: formId, Day: day}]++
+	c.visitors[visitor] = struct{}{}
+	c.mu.Unlock()
+}
+
+// daySalt returns the salt for day, replacing and wiping the previous day's.
+// Called with mu held.
+func (c *Counter) daySalt(day time.Time) []byte {
+	if c.salt == nil || !c.saltDay.Equal(day) {
+		clear(c.salt)
+		c.salt = NewSalt()
+		c.saltDay = day
+	}
+	return c.salt
+}
+
+func (c *Counter) Start() {
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.Flush()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the flush loop and writes whatever is still buffered
+func (c *Counter) Stop() {
+	close(c.stop)
+	<-c.done
+	c.Flush()
+}
+
+// Flush writes the buffered counts, on failure they are put back to be
+// retried on the next flush
+func (c *Counter) Flush() {
+	c.mu.Lock()
+	counts, visitors := c.counts, c.visitors
+	c.counts, c.visitors = map[form_repo.ViewKey]int{}, map[form_repo.Visitor]struct{}{}
+	c.mu.Unlock()
+
+	if len(counts) == 0 {
+		return
+	}
+
+	batch := make([]form_repo.Visitor, 0, len(visitors))
+	for v := range visitors {
+		batch = append(batch, v)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.store.AddViews(ctx, counts, batch); err != nil {
+		log.Printf("View flush failed, retrying next interval: %v", err)
+
+		c.mu.Lock()
+		for key, n := range counts {
+			c.counts[key] += n
+		}
+		for v := range visitors {
+			c.visitors[v] = struct{}{}
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,118 @@
+package views_test
+
+import (
+	"context"
+	"errors"
+	form_repo "formaura/pkg/repositories/form"
+	"formaura/pkg/views"
+	"reflect"
+	"testing"
+)
+
+const browser = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0 Safari/537.36"
+
+type flush struct {
+	counts   map[int]int
+	visitors int
+}
+
+// perForm drops the day from buffered counts, every test runs within one day
+func perForm(counts map[form_repo.ViewKey]int) map[int]int {
+	totals := map[int]int{}
+	for key, n := range counts {
+		totals[key.FormID] += n
+	}
+	return totals
+}
+
+type fakeStore struct {
+	fail    bool
+	flushes []flush
+}
+
+func (s *fakeStore) AddViews(ctx context.Context, counts map[form_repo.ViewKey]int, visitors []form_repo.Visitor) error {
+	if s.fail {
+		return errors.New("db down")
+	}
+	s.flushes = append(s.flushes, flush{counts: perForm(counts), visitors: len(visitors)})
+	return nil
+}
+
+func TestCounterBatchesViews(t *testing.T) {
+	store := &fakeStore{}
+	counter := views.NewCounter(store)
+
+	counter.Add(1, "1.1.1.1", browser)
+	counter.Add(1, "1.1.1.1", browser)
+	counter.Add(1, "2.2.2.2", browser)
+	counter.Add(2, "1.1.1.1", browser)
+	counter.Flush()
+
+	// nothing buffered, nothing written
+	counter.Flush()
+
+	want := []flush{{counts: map[int]int{1: 3, 2: 1}, visitors: 3}}
+	if !reflect.DeepEqual(store.flushes, want) {
+		t.Errorf("expected %v, got %v", want, store.flushes)
+	}
+}
+
+func TestCounterIgnoresBots(t *testing.T) {
+	store := &fakeStore{}
+	counter := views.NewCounter(store)
+
+	counter.Add(1, "1.1.1.1", "Googlebot/2.1 (+http://www.google.com/bot.html)")
+	counter.Add(1, "1.1.1.1", "curl/8.4.0")
+	counter.Add(1, "1.1.1.1", "")
+	counter.Flush()
+
+	if len(store.flushes) != 0 {
+		t.Errorf("expected bots to be ignored, got %v", store.flushes)
+	}
+}
+
+func TestCounterRetriesFailedFlush(t *testing.T) {
+	store := &fakeStore{fail: true}
+	counter := views.NewCounter(store)
+
+	counter.Add(1, "1.1.1.1", browser)
+	counter.Flush()
+
+	store.fail = false
+	counter.Add(1, "1.1.1.1", browser)
+	counter.Flush()
+
+	want := []flush{{counts: map[int]int{1: 2}, visitors: 1}}
+	if !reflect.DeepEqual(store.flushes, want) {
+		t.Errorf("expected %v, got %v", want, store.flushes)
+	}
+}
+
+func TestCounterFlushesOnStop(t *testing.T) {
+	store := &fakeStore{}
+	counter := views.NewCounter(store)
+	counter.Start()
+
+	counter.Add(3, "1.1.1.1", browser)
+	counter.Stop()
+
+	want := []flush{{counts: map[int]int{3: 1}, visitors: 1}}
+	if !reflect.DeepEqual(store.flushes, want) {
+		t.Errorf("expected %v, got %v", want, store.flushes)
+	}
+}
+
+func TestVisitorHash(t *testing.T) {
+	salt := views.NewSalt()
+	hash := views.VisitorHash(salt, 1, "1.1.1.1", browser)
+
+	if hash != views.VisitorHash(salt, 1, "1.1.1.1", browser) {
+		t.Error("expected the same visitor to hash the same with the day's salt")
+	}
+	if hash == views.VisitorHash(views.NewSalt(), 1, "1.1.1.1", browser) {
+		t.Error("expected a new salt to change the hash")
+	}
+	if hash == views.VisitorHash(salt, 2, "1.1.1.1", browser) {
+		t.Error("expected a different hash on another form")
+	}
+}
@@ -0,0 +1,66 @@
+package views
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// botAgents are lower case fragments of user agents that are never counted
+var botAgents = []string{
+	"bot", "crawler", "spider", "slurp", "crawl", "archiver",
+	"facebookexternalhit", "embedly", "quora link preview", "whatsapp", "telegram",
+	"headless", "lighthouse", "pingdom", "uptime", "monitor",
+	"curl", "wget", "python-requests", "python-urllib", "go-http-client", "okhttp", "axios", "node-fetch", "java/",
+}
+
+// IsBot reports whether a user agent belongs to a crawler, link previewer or
+// script, an empty user agent counts as a bot
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, bot := range botAgents {
+		if strings.Contains(ua, bot) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSalt returns a random salt for one day of visitor hashes
+func NewSalt() []byte {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		log.Fatalf("views: unable to generate visitor salt: %v", err)
+	}
+	return salt
+}
+
+// Day truncates t to the UTC calendar day. Every daily figure, views, unique
+// visitors, form starts and submissions, is kept in UTC days.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// VisitorHash identifies a visitor to a form for one day. The salt is random,
+// only held in memory and replaced when the day changes, see Counter, so once
+// the day is over a hash cannot be linked to an IP even by someone with the
+// database and the server's configuration. It also differs per form.
+func VisitorHash(salt []byte, formId int, ip, userAgent string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(strconv.Itoa(formId)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}